@@ -17,13 +17,21 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/fsuhrau/buffalo-swagger/generator"
 	"github.com/fsuhrau/buffalo-swagger/parser"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
-var yamlExport bool
+var (
+	yamlExport bool
+	strict     bool
+	openAPI    string
+	jsonSchema bool
+)
 
 // swaggerCmd represents the swagger command
 var swaggerCmd = &cobra.Command{
@@ -40,22 +48,81 @@ var swaggerCmd = &cobra.Command{
 			outputFile = args[1]
 		}
 		parser := parser.NewParser(path)
-		err := parser.ParseProject()
+		parser.Models = viper.GetStringSlice("models.include")
+		parser.ExcludeModels = viper.GetStringSlice("models.exclude")
+		diagnostics, err := parser.ParseProject()
 		if err != nil {
+			printDiagnostics(diagnostics)
 			fmt.Println(err.Error())
 			os.Exit(1)
 		}
 
 		gen := generator.NewGenerator(outputFile)
+		err = viper.UnmarshalKey("security", &gen.Security)
+		if err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+		err = viper.UnmarshalKey("types", &gen.Types)
+		if err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+
+		err = viper.UnmarshalKey("servers", &gen.Servers)
+		if err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+		err = viper.UnmarshalKey("webhooks", &gen.Webhooks)
+		if err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+
+		gen.EnumVarNames = viper.GetBool("enumVarNames")
+		gen.OpenAPI = openAPI
+		gen.JSONSchema = jsonSchema
+
 		err = gen.Generate(parser, yamlExport)
+		diagnostics = append(diagnostics, gen.Diagnostics...)
+		printDiagnostics(diagnostics)
 		if err != nil {
 			fmt.Println(err.Error())
 			os.Exit(1)
 		}
+		if failed(diagnostics) {
+			os.Exit(1)
+		}
 	},
 }
 
+// failed reports whether the diagnostics fail the run, warnings only do
+// in strict mode.
+func failed(diagnostics parser.Diagnostics) bool {
+	return diagnostics.Count(parser.Error) > 0 || (strict && len(diagnostics) > 0)
+}
+
+// printDiagnostics writes the diagnostics to stderr with the file names
+// relative to the working directory, followed by a summary.
+func printDiagnostics(diagnostics parser.Diagnostics) {
+	if len(diagnostics) == 0 {
+		return
+	}
+	wd, _ := os.Getwd()
+	for _, d := range diagnostics {
+		if rel, err := filepath.Rel(wd, d.Position.Filename); err == nil && d.Position.Filename != "" && !strings.HasPrefix(rel, "..") {
+			d.Position.Filename = rel
+		}
+		fmt.Fprintln(os.Stderr, d)
+	}
+	fmt.Fprintf(os.Stderr, "%d error(s), %d warning(s)\n", diagnostics.Count(parser.Error), diagnostics.Count(parser.Warning))
+}
+
 func init() {
 	rootCmd.AddCommand(swaggerCmd)
 	swaggerCmd.Flags().BoolVarP(&yamlExport, "yaml", "y", false, "export as yaml")
+	swaggerCmd.Flags().BoolVar(&strict, "strict", false, "fail on warnings")
+	swaggerCmd.Flags().StringVar(&openAPI, "openapi", "", "generate OpenAPI 3.0 or 3.1 instead of Swagger 2.0")
+	swaggerCmd.Flags().BoolVar(&jsonSchema, "json-schema", false, "generate a JSON Schema of the definitions")
 }
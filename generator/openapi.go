@@ -0,0 +1,606 @@
+package generator
+
+import (
+	"encoding/json"
+	"go/token"
+	"strings"
+)
+
+const (
+	OPENAPI_VERSION    = "3.0.3"
+	OPENAPI_31_VERSION = "3.1.0"
+	// JSON_SCHEMA_DIALECT is the JSON Schema 2020-12 dialect of OpenAPI 3.1
+	JSON_SCHEMA_DIALECT = "https://spec.openapis.org/oas/3.1/dialect/base"
+	// COMPONENTS_SCHEMAS is where OpenAPI 3 keeps what Swagger 2.0 calls
+	// definitions
+	COMPONENTS_SCHEMAS  = "#/components/schemas/"
+	JSON_SCHEMA_2020_12 = "https://json-schema.org/draft/2020-12/schema"
+	// DEFS is where a JSON Schema keeps the schemas it references
+	DEFS = "#/$defs/"
+)
+
+// Server is a base URL the API is served from.
+type Server struct {
+	Url         string `json:"url"`
+	Description string `json:"description,omitempty" yaml:",omitempty"`
+}
+
+// Webhook is a request the API sends to its clients, the payload is a Go
+// type of the project.
+type Webhook struct {
+	Name        string
+	Method      string
+	Description string
+	Type        string
+}
+
+// SchemaObject is an OpenAPI 3 schema, which merges the definitions,
+// properties, items and schemas of Swagger 2.0.
+type SchemaObject struct {
+	Type                 SchemaType               `json:"type,omitempty" yaml:",omitempty"`
+	Format               string                   `json:"format,omitempty" yaml:",omitempty"`
+	Description          string                   `json:"description,omitempty" yaml:",omitempty"`
+	Enum                 []interface{}            `json:"enum,omitempty" yaml:",omitempty"`
+	EnumVarNames         []string                 `json:"x-enum-varnames,omitempty" yaml:"x-enum-varnames,omitempty"`
+	Ref                  string                   `json:"$ref,omitempty" yaml:"$ref,omitempty"`
+	AllOf                []*SchemaObject          `json:"allOf,omitempty" yaml:"allOf,omitempty"`
+	AnyOf                []*SchemaObject          `json:"anyOf,omitempty" yaml:"anyOf,omitempty"`
+	Default              interface{}              `json:"default,omitempty" yaml:",omitempty"`
+	Required             []string                 `json:"required,omitempty" yaml:",omitempty"`
+	Properties           map[string]*SchemaObject `json:"properties,omitempty" yaml:",omitempty"`
+	Items                *SchemaObject            `json:"items,omitempty" yaml:",omitempty"`
+	AdditionalProperties *SchemaObject            `json:"additionalProperties,omitempty" yaml:"additionalProperties,omitempty"`
+	Nullable             bool                     `json:"nullable,omitempty" yaml:",omitempty"`
+	Pattern              string                   `json:"pattern,omitempty" yaml:",omitempty"`
+	MinLength            *int64                   `json:"minLength,omitempty" yaml:"minLength,omitempty"`
+	MaxLength            *int64                   `json:"maxLength,omitempty" yaml:"maxLength,omitempty"`
+	Minimum              *float64                 `json:"minimum,omitempty" yaml:",omitempty"`
+	Maximum              *float64                 `json:"maximum,omitempty" yaml:",omitempty"`
+	// ExclusiveMinimum and ExclusiveMaximum are true in OpenAPI 3.0 and the
+	// bound itself in 3.1
+	ExclusiveMinimum interface{}   `json:"exclusiveMinimum,omitempty" yaml:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum interface{}   `json:"exclusiveMaximum,omitempty" yaml:"exclusiveMaximum,omitempty"`
+	MinItems         *int64        `json:"minItems,omitempty" yaml:"minItems,omitempty"`
+	MaxItems         *int64        `json:"maxItems,omitempty" yaml:"maxItems,omitempty"`
+	Example          interface{}   `json:"example,omitempty" yaml:",omitempty"`
+	Examples         []interface{} `json:"examples,omitempty" yaml:",omitempty"`
+	ReadOnly         bool          `json:"readOnly,omitempty" yaml:"readOnly,omitempty"`
+	WriteOnly        bool          `json:"writeOnly,omitempty" yaml:"writeOnly,omitempty"`
+	Deprecated       bool          `json:"deprecated,omitempty" yaml:",omitempty"`
+	Xml              *Xml          `json:"xml,omitempty" yaml:",omitempty"`
+	Association      string        `json:"x-association,omitempty" yaml:"x-association,omitempty"`
+	ForeignKey       string        `json:"x-foreign-key,omitempty" yaml:"x-foreign-key,omitempty"`
+	JoinTable        string        `json:"x-join-table,omitempty" yaml:"x-join-table,omitempty"`
+}
+
+type OperationParameter struct {
+	In          string        `json:"in"`
+	Name        string        `json:"name"`
+	Description string        `json:"description,omitempty" yaml:",omitempty"`
+	Required    bool          `json:"required,omitempty" yaml:",omitempty"`
+	Schema      *SchemaObject `json:"schema,omitempty" yaml:",omitempty"`
+}
+
+type OperationResponse struct {
+	Description string            `json:"description"`
+	Headers     map[string]Header `json:"headers,omitempty" yaml:",omitempty"`
+	Content     map[string]Body   `json:"content,omitempty" yaml:",omitempty"`
+}
+
+type Header struct {
+	Description string        `json:"description,omitempty" yaml:",omitempty"`
+	Schema      *SchemaObject `json:"schema,omitempty" yaml:",omitempty"`
+}
+
+type Operation struct {
+	Tags        []string                     `json:"tags,omitempty" yaml:",omitempty"`
+	Summary     string                       `json:"summary,omitempty" yaml:",omitempty"`
+	Description string                       `json:"description,omitempty" yaml:",omitempty"`
+	OperationID string                       `json:"operationId,omitempty" yaml:"operationId"`
+	Parameters  []OperationParameter         `json:"parameters,omitempty" yaml:",omitempty"`
+	RequestBody *RequestBody                 `json:"requestBody,omitempty" yaml:"requestBody,omitempty"`
+	Responses   map[string]OperationResponse `json:"responses,omitempty" yaml:",omitempty"`
+	Security    []Auth                       `json:"security,omitempty" yaml:",omitempty"`
+	Deprecated  bool                         `json:"deprecated,omitempty" yaml:",omitempty"`
+}
+
+type OAuthFlow struct {
+	AuthorizationUrl string            `json:"authorizationUrl,omitempty" yaml:"authorizationUrl,omitempty"`
+	TokenUrl         string            `json:"tokenUrl,omitempty" yaml:"tokenUrl,omitempty"`
+	Scopes           map[string]string `json:"scopes"`
+}
+
+type OAuthFlows struct {
+	Implicit          *OAuthFlow `json:"implicit,omitempty" yaml:",omitempty"`
+	Password          *OAuthFlow `json:"password,omitempty" yaml:",omitempty"`
+	ClientCredentials *OAuthFlow `json:"clientCredentials,omitempty" yaml:"clientCredentials,omitempty"`
+	AuthorizationCode *OAuthFlow `json:"authorizationCode,omitempty" yaml:"authorizationCode,omitempty"`
+}
+
+type SecurityComponent struct {
+	Type        string      `json:"type"`
+	Description string      `json:"description,omitempty" yaml:",omitempty"`
+	Name        string      `json:"name,omitempty" yaml:",omitempty"`
+	In          string      `json:"in,omitempty" yaml:",omitempty"`
+	Scheme      string      `json:"scheme,omitempty" yaml:",omitempty"`
+	Flows       *OAuthFlows `json:"flows,omitempty" yaml:",omitempty"`
+}
+
+type Components struct {
+	Schemas         map[string]*SchemaObject     `json:"schemas,omitempty" yaml:",omitempty"`
+	SecuritySchemes map[string]SecurityComponent `json:"securitySchemes,omitempty" yaml:"securitySchemes,omitempty"`
+}
+
+type OpenAPI struct {
+	OpenAPI           string                          `json:"openapi" yaml:"openapi"`
+	Info              Info                            `json:"info"`
+	JSONSchemaDialect string                          `json:"jsonSchemaDialect,omitempty" yaml:"jsonSchemaDialect,omitempty"`
+	Servers           []Server                        `json:"servers,omitempty" yaml:",omitempty"`
+	Tags              []Tag                           `json:"tags,omitempty" yaml:",omitempty"`
+	Paths             map[string]map[string]Operation `json:"paths"`
+	Webhooks          map[string]map[string]Operation `json:"webhooks,omitempty" yaml:",omitempty"`
+	Components        Components                      `json:"components,omitempty" yaml:",omitempty"`
+	ExternalDocs      *ExternalDoc                    `json:"externalDocs,omitempty" yaml:"externalDocs,omitempty"`
+}
+
+// JSONSchema is a JSON Schema 2020-12 document of the definitions.
+type JSONSchema struct {
+	Schema string                   `json:"$schema" yaml:"$schema"`
+	Defs   map[string]*SchemaObject `json:"$defs" yaml:"$defs"`
+}
+
+// openAPI converts a Swagger 2.0 document to OpenAPI 3.0. Definitions move
+// to the schemas of the components, body parameters to request bodies and
+// the schemas of responses into a content map of the produced media types.
+func (g *Generator) openAPI(swagger Swagger) OpenAPI {
+	document := OpenAPI{
+		OpenAPI:      OPENAPI_VERSION,
+		Info:         swagger.Info,
+		Servers:      g.Servers,
+		Tags:         swagger.Tags,
+		Paths:        map[string]map[string]Operation{},
+		ExternalDocs: swagger.ExternalDocs,
+	}
+	if len(document.Servers) == 0 {
+		document.Servers = []Server{{Url: "/"}}
+	}
+
+	for routePath, endpoints := range swagger.Paths {
+		document.Paths[routePath] = map[string]Operation{}
+		for method, endpoint := range endpoints {
+			document.Paths[routePath][method] = operation(endpoint)
+		}
+	}
+
+	if len(swagger.Definitions) > 0 {
+		document.Components.Schemas = map[string]*SchemaObject{}
+		for key, definition := range swagger.Definitions {
+			document.Components.Schemas[key] = definitionSchema(definition)
+		}
+	}
+	if len(swagger.SecurityDefinitions) > 0 {
+		document.Components.SecuritySchemes = map[string]SecurityComponent{}
+		for key, security := range swagger.SecurityDefinitions {
+			document.Components.SecuritySchemes[key] = securityComponent(security)
+		}
+	}
+	return document
+}
+
+// openAPI31 converts a Swagger 2.0 document to OpenAPI 3.1, whose schemas
+// are JSON Schema 2020-12 referencing each other in the components.
+func (g *Generator) openAPI31(swagger Swagger) OpenAPI {
+	document := g.openAPI(swagger)
+	document.OpenAPI = OPENAPI_31_VERSION
+	document.JSONSchemaDialect = JSON_SCHEMA_DIALECT
+
+	for _, webhook := range g.Webhooks {
+		schema := g.typeSchema(webhook.Type)
+		if schema == nil {
+			g.Diagnostics.Warnf(token.Position{}, "skipping webhook %s of unknown type %s", webhook.Name, webhook.Type)
+			continue
+		}
+		method := strings.ToLower(webhook.Method)
+		if method == "" {
+			method = "post"
+		}
+		if document.Webhooks == nil {
+			document.Webhooks = map[string]map[string]Operation{}
+		}
+		if _, ok := document.Webhooks[webhook.Name]; !ok {
+			document.Webhooks[webhook.Name] = map[string]Operation{}
+		}
+		document.Webhooks[webhook.Name][method] = Operation{
+			Summary: webhook.Description,
+			RequestBody: &RequestBody{
+				Required: true,
+				Content:  content(nil, schemaObject(schema)),
+			},
+			Responses: map[string]OperationResponse{
+				"200": {Description: "Return a 200 status to indicate that the data was received successfully"},
+			},
+		}
+	}
+
+	for _, operations := range []map[string]map[string]Operation{document.Paths, document.Webhooks} {
+		for _, methods := range operations {
+			for _, op := range methods {
+				for _, param := range op.Parameters {
+					jsonSchema(param.Schema)
+				}
+				if op.RequestBody != nil {
+					for _, body := range op.RequestBody.Content {
+						jsonSchema(body.Schema)
+					}
+				}
+				for _, res := range op.Responses {
+					for _, header := range res.Headers {
+						jsonSchema(header.Schema)
+					}
+					for _, body := range res.Content {
+						jsonSchema(body.Schema)
+					}
+				}
+			}
+		}
+	}
+	for _, schema := range document.Components.Schemas {
+		jsonSchema(schema)
+	}
+	return document
+}
+
+// jsonSchemaDefs converts the definitions of a Swagger 2.0 document to the
+// $defs of a JSON Schema, which validators can use as they are.
+func jsonSchemaDefs(swagger Swagger) JSONSchema {
+	document := JSONSchema{
+		Schema: JSON_SCHEMA_2020_12,
+		Defs:   map[string]*SchemaObject{},
+	}
+	for key, definition := range swagger.Definitions {
+		schema := definitionSchema(definition)
+		jsonSchema(schema)
+		defsRefs(schema)
+		document.Defs[key] = schema
+	}
+	return document
+}
+
+// defsRefs points the references of a schema at the $defs.
+func defsRefs(schema *SchemaObject) {
+	if schema == nil {
+		return
+	}
+	if strings.HasPrefix(schema.Ref, COMPONENTS_SCHEMAS) {
+		schema.Ref = DEFS + strings.TrimPrefix(schema.Ref, COMPONENTS_SCHEMAS)
+	}
+	for _, property := range schema.Properties {
+		defsRefs(property)
+	}
+	defsRefs(schema.Items)
+	defsRefs(schema.AdditionalProperties)
+	for _, s := range schema.AllOf {
+		defsRefs(s)
+	}
+	for _, s := range schema.AnyOf {
+		defsRefs(s)
+	}
+}
+
+// jsonSchema rewrites an OpenAPI 3.0 schema to JSON Schema 2020-12. Null
+// becomes a type of nullable schemas, examples are lists and exclusive
+// bounds are numbers.
+func jsonSchema(schema *SchemaObject) {
+	if schema == nil {
+		return
+	}
+	for _, property := range schema.Properties {
+		jsonSchema(property)
+	}
+	jsonSchema(schema.Items)
+	jsonSchema(schema.AdditionalProperties)
+	for _, s := range schema.AnyOf {
+		jsonSchema(s)
+	}
+
+	// $ref may have siblings in JSON Schema 2020-12
+	if len(schema.AllOf) == 1 && schema.AllOf[0].Ref != "" && schema.Ref == "" {
+		schema.Ref = schema.AllOf[0].Ref
+		schema.AllOf = nil
+	}
+	if schema.Nullable {
+		switch {
+		case schema.Ref != "":
+			schema.AnyOf = []*SchemaObject{{Ref: schema.Ref}, {Type: schemaType("null")}}
+			schema.Ref = ""
+		case len(schema.Type) > 0:
+			schema.Type = append(schema.Type, "null")
+			if schema.Enum != nil {
+				schema.Enum = append(schema.Enum, nil)
+			}
+		}
+		schema.Nullable = false
+	}
+
+	if schema.Example != nil {
+		schema.Examples = []interface{}{schema.Example}
+		schema.Example = nil
+	}
+	if schema.ExclusiveMinimum == true && schema.Minimum != nil {
+		schema.ExclusiveMinimum = *schema.Minimum
+		schema.Minimum = nil
+	}
+	if schema.ExclusiveMaximum == true && schema.Maximum != nil {
+		schema.ExclusiveMaximum = *schema.Maximum
+		schema.Maximum = nil
+	}
+}
+
+func operation(endpoint Endpoint) Operation {
+	op := Operation{
+		Tags:        endpoint.Tags,
+		Summary:     endpoint.Summary,
+		Description: endpoint.Description,
+		OperationID: endpoint.OperationID,
+		Responses:   map[string]OperationResponse{},
+		Security:    endpoint.Security,
+		Deprecated:  endpoint.Deprecated,
+	}
+
+	for _, param := range endpoint.Parameters {
+		if param.In == "body" {
+			op.RequestBody = &RequestBody{
+				Description: param.Description,
+				Required:    param.Required,
+				Content:     content(endpoint.Consumes, schemaObject(param.Schema)),
+			}
+			// forms name the fields differently
+			if endpoint.form != nil {
+				for _, mediaType := range formContentTypes {
+					op.RequestBody.Content[mediaType] = Body{Schema: schemaObject(endpoint.form)}
+				}
+			}
+			continue
+		}
+		op.Parameters = append(op.Parameters, OperationParameter{
+			In:          param.In,
+			Name:        param.Name,
+			Description: param.Description,
+			Required:    param.Required,
+			Schema: &SchemaObject{
+				Type:   schemaType(param.Type),
+				Format: param.Format,
+			},
+		})
+	}
+
+	for code, response := range endpoint.Responses {
+		res := OperationResponse{
+			Description: response.Description,
+		}
+		if response.Schema != nil {
+			res.Content = content(endpoint.Produces, schemaObject(response.Schema))
+		}
+		for name, header := range response.Headers {
+			if res.Headers == nil {
+				res.Headers = map[string]Header{}
+			}
+			res.Headers[name] = Header{
+				Description: header.Description,
+				Schema: &SchemaObject{
+					Type:   schemaType(header.Type),
+					Format: header.Format,
+				},
+			}
+		}
+		op.Responses[code] = res
+	}
+	return op
+}
+
+// content maps the media types to a schema, JSON when there are none.
+func content(mediaTypes []string, schema *SchemaObject) map[string]Body {
+	if len(mediaTypes) == 0 {
+		mediaTypes = []string{APP_JSON}
+	}
+	bodies := map[string]Body{}
+	for _, mediaType := range mediaTypes {
+		bodies[mediaType] = Body{Schema: schema}
+	}
+	return bodies
+}
+
+// securityComponent converts a security definition, the oauth2 flows of
+// Swagger 2.0 were renamed.
+func securityComponent(security Security) SecurityComponent {
+	component := SecurityComponent{
+		Type:        security.Type,
+		Description: security.Description,
+		Name:        security.Name,
+		In:          security.In,
+	}
+	switch security.Type {
+	case "basic":
+		component.Type = "http"
+		component.Scheme = "basic"
+	case "oauth2":
+		flow := &OAuthFlow{
+			AuthorizationUrl: security.AuthorizationUrl,
+			TokenUrl:         security.TokenUrl,
+			Scopes:           security.Scopes,
+		}
+		if flow.Scopes == nil {
+			flow.Scopes = map[string]string{}
+		}
+		component.Flows = &OAuthFlows{}
+		switch security.Flow {
+		case "implicit":
+			flow.TokenUrl = ""
+			component.Flows.Implicit = flow
+		case "password":
+			flow.AuthorizationUrl = ""
+			component.Flows.Password = flow
+		case "application":
+			flow.AuthorizationUrl = ""
+			component.Flows.ClientCredentials = flow
+		default:
+			component.Flows.AuthorizationCode = flow
+		}
+	}
+	return component
+}
+
+// SchemaType is the type of a schema, OpenAPI 3.1 lists null as a second
+// type of nullable schemas.
+type SchemaType []string
+
+func schemaType(t string) SchemaType {
+	if t == "" {
+		return nil
+	}
+	return SchemaType{t}
+}
+
+func (t SchemaType) value() interface{} {
+	if len(t) == 1 {
+		return t[0]
+	}
+	return []string(t)
+}
+
+func (t SchemaType) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.value())
+}
+
+func (t SchemaType) MarshalYAML() (interface{}, error) {
+	return t.value(), nil
+}
+
+// exclusiveBound keeps exclusive bounds that are false out of the schema.
+func exclusiveBound(exclusive bool) interface{} {
+	if !exclusive {
+		return nil
+	}
+	return true
+}
+
+// componentRef points a reference to a definition at the schemas of the
+// components.
+func componentRef(ref string) string {
+	if strings.HasPrefix(ref, "#/definitions/") {
+		return COMPONENTS_SCHEMAS + strings.TrimPrefix(ref, "#/definitions/")
+	}
+	return ref
+}
+
+func schemaObject(schema *Schema) *SchemaObject {
+	if schema == nil {
+		return nil
+	}
+	object := &SchemaObject{
+		Type:                 schemaType(schema.Type),
+		Format:               schema.Format,
+		Required:             schema.Required,
+		Ref:                  componentRef(schema.Ref),
+		Items:                schemaObject(schema.Items),
+		AdditionalProperties: schemaObject(schema.AdditionalProperties),
+	}
+	for name, property := range schema.Properties {
+		if object.Properties == nil {
+			object.Properties = map[string]*SchemaObject{}
+		}
+		object.Properties[name] = &SchemaObject{
+			Type:        schemaType(property.Type),
+			Format:      property.Format,
+			Description: property.Description,
+		}
+	}
+	return object
+}
+
+func definitionSchema(definition Definition) *SchemaObject {
+	return &SchemaObject{
+		Type:        schemaType(definition.Type),
+		Description: definition.Description,
+		Required:    definition.Required,
+		Properties:  propertySchemas(definition.Properties),
+		Xml:         definition.Xml,
+		Items:       itemSchema(definition.Items),
+	}
+}
+
+func propertySchemas(properties map[string]DefinitionProperty) map[string]*SchemaObject {
+	if len(properties) == 0 {
+		return nil
+	}
+	schemas := map[string]*SchemaObject{}
+	for name, property := range properties {
+		schemas[name] = propertySchema(&property)
+	}
+	return schemas
+}
+
+func propertySchema(property *DefinitionProperty) *SchemaObject {
+	if property == nil {
+		return nil
+	}
+	return &SchemaObject{
+		Type:                 schemaType(property.Type),
+		Format:               property.Format,
+		Description:          property.Description,
+		Enum:                 property.Enum,
+		EnumVarNames:         property.EnumVarNames,
+		Ref:                  componentRef(property.Ref),
+		AllOf:                allOfSchemas(property.AllOf),
+		Default:              property.Default,
+		Required:             property.Required,
+		Properties:           propertySchemas(property.Properties),
+		Items:                itemSchema(property.Items),
+		AdditionalProperties: propertySchema(property.AdditionalProperties),
+		Nullable:             property.Nullable,
+		Pattern:              property.Pattern,
+		MinLength:            property.MinLength,
+		MaxLength:            property.MaxLength,
+		Minimum:              property.Minimum,
+		Maximum:              property.Maximum,
+		ExclusiveMinimum:     exclusiveBound(property.ExclusiveMinimum),
+		ExclusiveMaximum:     exclusiveBound(property.ExclusiveMaximum),
+		MinItems:             property.MinItems,
+		MaxItems:             property.MaxItems,
+		Example:              property.Example,
+		ReadOnly:             property.ReadOnly,
+		WriteOnly:            property.WriteOnly,
+		Deprecated:           property.Deprecated,
+		Association:          property.Association,
+		ForeignKey:           property.ForeignKey,
+		JoinTable:            property.JoinTable,
+	}
+}
+
+func allOfSchemas(properties []DefinitionProperty) []*SchemaObject {
+	var schemas []*SchemaObject
+	for i := range properties {
+		schemas = append(schemas, propertySchema(&properties[i]))
+	}
+	return schemas
+}
+
+func itemSchema(item *DefinitionItem) *SchemaObject {
+	if item == nil {
+		return nil
+	}
+	schema := &SchemaObject{
+		Type:                 schemaType(item.Type),
+		Format:               item.Format,
+		Enum:                 item.Enum,
+		EnumVarNames:         item.EnumVarNames,
+		Ref:                  componentRef(item.Ref),
+		Items:                itemSchema(item.Items),
+		AdditionalProperties: propertySchema(item.AdditionalProperties),
+		Nullable:             item.Nullable,
+	}
+	for i := range item.AllOf {
+		schema.AllOf = append(schema.AllOf, itemSchema(&item.AllOf[i]))
+	}
+	return schema
+}
@@ -0,0 +1,165 @@
+package generator
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestGenerateOpenAPIForms(t *testing.T) {
+	document := generate(t, func(g *Generator) {
+		g.OpenAPI = "3.0"
+	})
+
+	// form bodies are keyed by the form tags, fields that aren't bound
+	// from forms like associations and read only fields are left out and
+	// json:"-" fields are kept
+	form := `{
+		"type": "object",
+		"required": ["widget_title"],
+		"properties": {
+			"CreatedAt": {"type": "string", "format": "date-time"},
+			"Description": {"type": "string"},
+			"ID": {"type": "string", "format": "uuid"},
+			"OwnerID": {"type": "string", "format": "uuid"},
+			"Price": {"type": "integer", "format": "int64"},
+			"Priority": {"type": "integer", "format": "int64"},
+			"Retired": {"type": "string", "format": "date-time"},
+			"Secret": {"type": "string"},
+			"Status": {"type": "string"},
+			"UpdatedAt": {"type": "string", "format": "date-time"},
+			"password": {"type": "string"},
+			"widget_title": {"type": "string", "description": "Title is shown in listings."}
+		}
+	}`
+	runDocumentTests(t, document, []documentTest{
+		{[]string{"paths", "/widgets", "post", "requestBody", "content", "application/x-www-form-urlencoded", "schema"}, form},
+		{[]string{"paths", "/widgets", "post", "requestBody", "content", "multipart/form-data", "schema"}, form},
+		{[]string{"paths", "/widgets", "post", "requestBody", "content", "application/xml"}, `{
+			"schema": {"$ref": "#/components/schemas/Widget"}
+		}`},
+	})
+
+	// the JSON schema leaves json:"-" fields out
+	widget := document["components"].(map[string]interface{})["schemas"].(map[string]interface{})["Widget"].(map[string]interface{})
+	for _, name := range []string{"Secret", "Password", "password"} {
+		if _, ok := widget["properties"].(map[string]interface{})[name]; ok {
+			t.Errorf("Widget has the json:\"-\" property %s", name)
+		}
+	}
+}
+
+func TestGenerateOpenAPI30(t *testing.T) {
+	document := generate(t, func(g *Generator) {
+		g.OpenAPI = "3.0"
+	})
+	runDocumentTests(t, document, []documentTest{
+		{[]string{"openapi"}, `"3.0.3"`},
+		{[]string{"servers"}, `[{"url": "/"}]`},
+		{[]string{"paths", "/api/v1/admin/stats", "get", "parameters"}, `[
+			{"in": "query", "name": "days", "schema": {"type": "integer", "format": "int64"}}
+		]`},
+		{[]string{"paths", "/widgets", "post", "requestBody", "content", "application/json"}, `{
+			"schema": {"$ref": "#/components/schemas/Widget"}
+		}`},
+		{[]string{"paths", "/widgets", "post", "responses", "201"}, `{
+			"description": "Created",
+			"content": {"application/json": {"schema": {"$ref": "#/components/schemas/Widget"}}}
+		}`},
+		{[]string{"paths", "/widgets/{widget_id}", "delete", "responses", "204"}, `{"description": "No Content"}`},
+		{[]string{"components", "schemas", "Widgets", "items"}, `{"$ref": "#/components/schemas/Widget"}`},
+		{[]string{"components", "schemas", "Widget", "properties", "owner"}, `{
+			"allOf": [{"$ref": "#/components/schemas/User"}],
+			"nullable": true, "x-association": "belongs_to", "x-foreign-key": "owner_id"
+		}`},
+		{[]string{"components", "schemas", "Widget", "properties", "description"}, `{"type": "string", "nullable": true}`},
+		{[]string{"components", "schemas", "Widget", "properties", "price"}, `{
+			"type": "integer", "format": "int64", "minimum": 0, "exclusiveMinimum": true
+		}`},
+	})
+}
+
+func TestGenerateUnsupportedVersion(t *testing.T) {
+	g := NewGenerator(filepath.Join(t.TempDir(), "swagger.json"))
+	g.OpenAPI = "4.0"
+	if err := g.Generate(parseFixture(t), false); err == nil {
+		t.Errorf("Generate() with OpenAPI %s succeeded", g.OpenAPI)
+	}
+}
+
+func TestGenerateOpenAPI31(t *testing.T) {
+	document := generate(t, func(g *Generator) {
+		g.OpenAPI = "3.1"
+	})
+	runDocumentTests(t, document, []documentTest{
+		{[]string{"openapi"}, `"3.1.0"`},
+		{[]string{"jsonSchemaDialect"}, `"https://spec.openapis.org/oas/3.1/dialect/base"`},
+		{[]string{"paths", "/widgets/{widget_id}", "get", "parameters"}, `[
+			{"in": "path", "name": "widget_id", "required": true, "schema": {"type": "string", "format": "uuid"}}
+		]`},
+		{[]string{"components", "schemas", "Widget", "properties", "owner"}, `{
+			"anyOf": [{"$ref": "#/components/schemas/User"}, {"type": "null"}],
+			"x-association": "belongs_to", "x-foreign-key": "owner_id"
+		}`},
+		{[]string{"components", "schemas", "Widget", "properties", "description"}, `{"type": ["string", "null"]}`},
+		{[]string{"components", "schemas", "Widget", "properties", "retired"}, `{"type": ["string", "null"], "format": "date-time"}`},
+		{[]string{"components", "schemas", "Widget", "properties", "price"}, `{
+			"type": "integer", "format": "int64", "exclusiveMinimum": 0
+		}`},
+		// $ref may have siblings in JSON Schema 2020-12
+		{[]string{"components", "schemas", "User", "properties", "widgets"}, `{
+			"$ref": "#/components/schemas/Widgets",
+			"x-association": "has_many", "x-foreign-key": "owner_id"
+		}`},
+	})
+}
+
+func TestGenerateOpenAPI31Webhooks(t *testing.T) {
+	document := generate(t, func(g *Generator) {
+		g.OpenAPI = "3.1"
+		g.Servers = []Server{{Url: "https://api.example.com", Description: "production"}}
+		g.Webhooks = []Webhook{{Name: "widgetCreated", Description: "A widget was created", Type: "coke/models.Widget"}}
+	})
+	runDocumentTests(t, document, []documentTest{
+		{[]string{"servers"}, `[{"url": "https://api.example.com", "description": "production"}]`},
+		{[]string{"webhooks", "widgetCreated", "post", "summary"}, `"A widget was created"`},
+		{[]string{"webhooks", "widgetCreated", "post", "requestBody"}, `{
+			"required": true,
+			"content": {"application/json": {"schema": {"$ref": "#/components/schemas/Widget"}}}
+		}`},
+	})
+}
+
+func TestGenerateJSONSchema(t *testing.T) {
+	document := generate(t, func(g *Generator) {
+		g.JSONSchema = true
+	})
+	runDocumentTests(t, document, []documentTest{
+		{[]string{"$schema"}, `"https://json-schema.org/draft/2020-12/schema"`},
+		{[]string{"$defs", "Widgets"}, `{
+			"type": "array",
+			"description": "Widgets is not required by pop and may be deleted",
+			"items": {"$ref": "#/$defs/Widget"}
+		}`},
+		{[]string{"$defs", "Widget", "properties", "owner"}, `{
+			"anyOf": [{"$ref": "#/$defs/User"}, {"type": "null"}],
+			"x-association": "belongs_to", "x-foreign-key": "owner_id"
+		}`},
+		{[]string{"$defs", "Widget", "properties", "tags", "items"}, `{"$ref": "#/$defs/Tag"}`},
+	})
+	for _, key := range []string{"swagger", "openapi", "paths", "components"} {
+		if _, ok := document[key]; ok {
+			t.Errorf("JSON Schema has %s", key)
+		}
+	}
+}
+
+func TestGenerateJSONSchemaWithOpenAPI(t *testing.T) {
+	for _, version := range []string{"3.1", "4.0"} {
+		g := NewGenerator(filepath.Join(t.TempDir(), "swagger.json"))
+		g.JSONSchema = true
+		g.OpenAPI = version
+		if err := g.Generate(parseFixture(t), false); err == nil {
+			t.Errorf("Generate() of a JSON Schema with OpenAPI %s succeeded", version)
+		}
+	}
+}
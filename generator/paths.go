@@ -0,0 +1,325 @@
+package generator
+
+import (
+	"go/doc"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/fsuhrau/buffalo-swagger/parser"
+	"github.com/markbates/inflect"
+)
+
+// pathParamRegexp matches the {name} and {name:pattern} segments of a
+// buffalo route.
+var pathParamRegexp = regexp.MustCompile(`\{([^}:]+)(:[^}]*)?\}`)
+
+// operationIDRegexp matches everything that can't be part of an operationId.
+var operationIDRegexp = regexp.MustCompile(`[^A-Za-z0-9]+`)
+
+// bindContentTypes are the content types buffalo's c.Bind decodes into
+// the body parameter.
+var bindContentTypes = []string{APP_JSON, APP_XML}
+
+// formContentTypes are the form content types buffalo's c.Bind decodes.
+// Swagger 2.0 can't describe them next to a body parameter, so they are
+// only added to the request bodies of OpenAPI 3.
+var formContentTypes = []string{APP_FORM, MULTIPART_FORM}
+
+// renderContentTypes maps render.Engine methods to the content types
+// they produce.
+var renderContentTypes = map[string][]string{
+	"JSON":   {APP_JSON},
+	"XML":    {APP_XML},
+	"Auto":   {APP_JSON, APP_XML},
+	"String": {"text/plain"},
+	"HTML":   {"text/html"},
+}
+
+func swaggerPath(routePath string) string {
+	return pathParamRegexp.ReplaceAllString(routePath, "{$1}")
+}
+
+func pathParameters(routePath string) []Parameter {
+	var params []Parameter
+	for _, match := range pathParamRegexp.FindAllStringSubmatch(routePath, -1) {
+		params = append(params, Parameter{
+			Name:     match[1],
+			In:       "path",
+			Required: true,
+			Type:     "string",
+		})
+	}
+	return params
+}
+
+// parameters returns the path parameters of the route and the query
+// parameters read by its handler, typed by the handler's conversions.
+func (g *Generator) parameters(route parser.Route, handler *parser.Handler) []Parameter {
+	params := pathParameters(route.Path)
+	if handler == nil {
+		return params
+	}
+
+	for _, param := range handler.Params {
+		paramType, format := g.parameterType(param.Type)
+		found := false
+		for i := range params {
+			if params[i].In == "path" && params[i].Name == param.Name {
+				params[i].Type = paramType
+				params[i].Format = format
+				found = true
+			}
+		}
+		if !found {
+			params = append(params, Parameter{
+				Name:   param.Name,
+				In:     "query",
+				Type:   paramType,
+				Format: format,
+			})
+		}
+	}
+	return params
+}
+
+// parameterType returns the type and format of a parameter, values that
+// aren't primitive are passed as strings.
+func (g *Generator) parameterType(goType string) (string, string) {
+	m, ok := g.types.lookup(goType)
+	if ok && m.As != "" {
+		return g.parameterType(m.As)
+	}
+	switch m.Type {
+	case "string", "integer", "number", "boolean":
+		return m.Type, m.Format
+	}
+	return "string", ""
+}
+
+func operationID(route parser.Route, used map[string]bool) string {
+	id := inflect.CamelizeDownFirst(operationIDRegexp.ReplaceAllString(route.Handler, "_"))
+	if id == "" {
+		id = strings.ToLower(route.Method)
+	}
+	if used[id] {
+		id += inflect.Camelize(strings.ToLower(route.Method))
+	}
+	used[id] = true
+	return id
+}
+
+func (g *Generator) routeEndpoint(route parser.Route, handler *parser.Handler, operationIDs map[string]bool) Endpoint {
+	endpoint := Endpoint{
+		Summary:     route.Handler,
+		OperationID: operationID(route, operationIDs),
+		Parameters:  g.parameters(route, handler),
+		Responses:   map[string]Response{},
+	}
+	if handler != nil && handler.Doc != "" {
+		endpoint.Summary = (&doc.Package{}).Synopsis(handler.Doc)
+		if handler.Doc != endpoint.Summary {
+			endpoint.Description = handler.Doc
+		}
+	}
+	if route.Resource != "" {
+		endpoint.Tags = []string{inflect.Underscore(route.Resource)}
+	}
+	if auth := g.routeSecurity(route); len(auth) > 0 {
+		endpoint.Security = []Auth{auth}
+	}
+
+	if handler != nil {
+		for _, res := range handler.Responses {
+			code := "default"
+			description := "Response with a computed status code"
+			if res.Status != 0 {
+				code = strconv.Itoa(res.Status)
+				description = http.StatusText(res.Status)
+			}
+			// the first render of a status wins
+			if _, ok := endpoint.Responses[code]; ok {
+				continue
+			}
+			response := Response{
+				Description: description,
+			}
+			if res.Type != "" {
+				response.Schema = g.typeSchema(res.Type)
+				if response.Schema == nil {
+					g.Diagnostics.Warnf(res.Position, "unknown response type %s", res.Type)
+				}
+			}
+			endpoint.Responses[code] = response
+			endpoint.Produces = appendUnique(endpoint.Produces, renderContentTypes[res.Render]...)
+		}
+	}
+
+	if handler != nil {
+		for _, e := range handler.Errors {
+			code := strconv.Itoa(e.Status)
+			if _, ok := endpoint.Responses[code]; ok {
+				continue
+			}
+			endpoint.Responses[code] = Response{
+				Description: http.StatusText(e.Status),
+				Schema:      g.errorSchema(e.Status),
+			}
+		}
+	}
+
+	if handler != nil && handler.Bind != nil {
+		schema := g.typeSchema(handler.Bind.Type)
+		if schema == nil {
+			g.Diagnostics.Warnf(handler.Bind.Position, "unknown body type %s", handler.Bind.Type)
+			schema = &Schema{Type: "object"}
+		}
+		endpoint.Consumes = bindContentTypes
+		endpoint.Parameters = append(endpoint.Parameters, Parameter{
+			In:       "body",
+			Name:     "body",
+			Required: true,
+			Schema:   schema,
+		})
+		endpoint.form = g.formSchema(handler.Bind.Type)
+	}
+
+	if len(endpoint.Responses) == 0 {
+		endpoint.Responses["200"] = Response{Description: "OK"}
+	}
+	if len(endpoint.Produces) == 0 {
+		endpoint.Produces = []string{APP_JSON}
+	}
+	return endpoint
+}
+
+// routeSecurity returns the security schemes enforced by the middleware of
+// a route. All of them have to be satisfied, so they share one requirement.
+func (g *Generator) routeSecurity(route parser.Route) Auth {
+	auth := Auth{}
+	for _, middleware := range route.Middleware {
+		for _, scheme := range g.Security {
+			if scheme.Middleware == middleware {
+				scopes := scheme.Scopes
+				if scopes == nil {
+					scopes = []string{}
+				}
+				auth[scheme.Scheme] = scopes
+			}
+		}
+	}
+	return auth
+}
+
+// errorSchema returns the schema of a custom error handler for the status
+// or buffalo's default error response.
+func (g *Generator) errorSchema(status int) *Schema {
+	if schema, ok := g.errorSchemas[status]; ok && schema != nil {
+		return schema
+	}
+	g.errorResponse = true
+	return &Schema{Ref: "#/definitions/" + ERROR_DEFINITION}
+}
+
+// formSchema returns the schema of a model bound from a form, the fields
+// are named by their form tags. Only fields of primitive types are
+// described, nil is returned for types that aren't models.
+func (g *Generator) formSchema(goType string) *Schema {
+	def, ok := g.definition(strings.TrimLeft(goType, "*"))
+	if !ok || def.Items != "" {
+		return nil
+	}
+	schema := &Schema{
+		Type:       "object",
+		Properties: map[string]Property{},
+	}
+	for _, prop := range def.Properties {
+		if prop.FormName == "-" || prop.Association != nil || prop.Overrides.ReadOnly {
+			continue
+		}
+		property, ok := g.typeProperty(prop.Type)
+		if !ok {
+			continue
+		}
+		switch property.Type {
+		case "string", "integer", "number", "boolean":
+		default:
+			continue
+		}
+		schema.Properties[prop.FormName] = Property{
+			Type:        property.Type,
+			Format:      property.Format,
+			Description: prop.Description,
+		}
+		if prop.Constraints.Required {
+			schema.Required = append(schema.Required, prop.FormName)
+		}
+	}
+	return schema
+}
+
+// typeSchema returns the schema of a Go type expression, or nil when the
+// type is unknown.
+func (g *Generator) typeSchema(goType string) *Schema {
+	goType = strings.TrimLeft(goType, "*")
+	if m, ok := g.types.lookup(goType); ok {
+		if m.As != "" {
+			return g.typeSchema(m.As)
+		}
+		return &Schema{Type: m.Type, Format: m.Format}
+	}
+
+	switch {
+	case strings.HasPrefix(goType, "["):
+		elem, _ := sliceElem(goType)
+		items := g.typeSchema(elem)
+		if items == nil {
+			items = &Schema{}
+		}
+		return &Schema{Type: "array", Items: items}
+	case strings.HasPrefix(goType, "map["):
+		return &Schema{Type: "object", AdditionalProperties: g.typeSchema(mapValueType(goType))}
+	}
+
+	if key, ok := g.definitionKey(goType); ok {
+		return &Schema{Ref: "#/definitions/" + key}
+	}
+	if underlying, ok := g.underlying[goType]; ok {
+		return g.typeSchema(underlying)
+	}
+	return nil
+}
+
+// mapValueType returns V of map[K]V.
+func mapValueType(goType string) string {
+	depth := 0
+	for i, c := range goType {
+		switch c {
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				return goType[i+1:]
+			}
+		}
+	}
+	return ""
+}
+
+func appendUnique(values []string, add ...string) []string {
+	for _, value := range add {
+		found := false
+		for _, v := range values {
+			if v == value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			values = append(values, value)
+		}
+	}
+	return values
+}
@@ -4,6 +4,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"reflect"
+	"strconv"
+	"strings"
 
 	"github.com/fsuhrau/buffalo-swagger/parser"
 	"gopkg.in/yaml.v2"
@@ -12,7 +15,11 @@ import (
 const (
 	APP_JSON        = "application/json"
 	APP_XML         = "application/xml"
+	APP_FORM        = "application/x-www-form-urlencoded"
+	MULTIPART_FORM  = "multipart/form-data"
 	SWAGGER_VERSION = "2.0"
+	// ERROR_DEFINITION is the definition of buffalo's default error response
+	ERROR_DEFINITION = "ErrorResponse"
 )
 
 type Contact struct {
@@ -45,10 +52,13 @@ type Tag struct {
 }
 
 type Schema struct {
-	Type       string              `json:"type,omitempty" yaml:",omitempty"`
-	Required   []string            `json:"required,omitempty" yaml:",omitempty"`
-	Properties map[string]Property `json:"properties,omitempty" yaml:",omitempty"`
-	Ref        string              `json:"$ref,omitempty" yaml:"oneOf,omitempty"`
+	Type                 string              `json:"type,omitempty" yaml:",omitempty"`
+	Format               string              `json:"format,omitempty" yaml:",omitempty"`
+	Required             []string            `json:"required,omitempty" yaml:",omitempty"`
+	Properties           map[string]Property `json:"properties,omitempty" yaml:",omitempty"`
+	Ref                  string              `json:"$ref,omitempty" yaml:"$ref,omitempty"`
+	Items                *Schema             `json:"items,omitempty" yaml:",omitempty"`
+	AdditionalProperties *Schema             `json:"additionalProperties,omitempty" yaml:"additionalProperties,omitempty"`
 }
 
 type Item struct {
@@ -75,28 +85,17 @@ type Property struct {
 	Description string `json:"description,omitempty" yaml:",omitempty"`
 }
 
-type ResponseSchema struct {
-	Type                 string    `json:"type,omitempty" yaml:",omitempty"`
-	Items                Schema    `json:"items,omitempty" yaml:",omitempty"`
-	AdditionalProperties *Property `json:"additionalProperties,omitempty" yaml:"additionalProperties,omitempty"`
-}
-
 type Response struct {
 	Description string              `json:"description"`
-	Schema      *ResponseSchema     `json:"schema,omitempty" yaml:",omitempty"`
+	Schema      *Schema             `json:"schema,omitempty" yaml:",omitempty"`
 	Headers     map[string]Property `json:"headers,omitempty" yaml:",omitempty"`
 }
 
 type Auth map[string][]string
 
-type BodySchema struct {
-	Type   string `json:"type,omitempty" yaml:",omitempty"`
-	Format string `json:"format,omitempty" yaml:",omitempty"`
-	Ref    string `json:"$ref,omitempty" yaml:"oneOf,omitempty"`
-}
-
+// Body is the OpenAPI 3 media type object of a request or response.
 type Body struct {
-	Schema BodySchema
+	Schema *SchemaObject `json:"schema,omitempty" yaml:",omitempty"`
 }
 
 type RequestBody struct {
@@ -117,33 +116,61 @@ type Endpoint struct {
 	Responses   map[string]Response `json:"responses,omitempty" yaml:",omitempty"`
 	Security    []Auth              `json:"security,omitempty" yaml:",omitempty"`
 	Deprecated  bool                `json:"deprecated,omitempty" yaml:",omitempty"`
+
+	// form is the schema of form encoded bodies, which Swagger 2.0 can't
+	// describe next to a body parameter
+	form *Schema
 }
 
 type Security struct {
-	// TODO
-	// "petstore_auth": {
-	// 	"type": "oauth2",
-	// 	"authorizationUrl": "http://petstore.swagger.io/oauth/dialog",
-	// 	"flow": "implicit",
-	// 	"scopes": {
-	// 	  "write:pets": "modify pets in your account",
-	// 	  "read:pets": "read your pets"
-	// 	}
-	//   },
-	//   "api_key": {
-	// 	"type": "apiKey",
-	// 	"name": "api_key",
-	// 	"in": "header"
-	//   }
+	Type             string            `json:"type,omitempty" yaml:",omitempty"`
+	Description      string            `json:"description,omitempty" yaml:",omitempty"`
+	Name             string            `json:"name,omitempty" yaml:",omitempty"`
+	In               string            `json:"in,omitempty" yaml:",omitempty"`
+	Flow             string            `json:"flow,omitempty" yaml:",omitempty"`
+	AuthorizationUrl string            `json:"authorizationUrl,omitempty" yaml:"authorizationUrl,omitempty"`
+	TokenUrl         string            `json:"tokenUrl,omitempty" yaml:"tokenUrl,omitempty"`
+	Scopes           map[string]string `json:"scopes,omitempty" yaml:",omitempty"`
+}
+
+// SecurityScheme maps a middleware to the security definition it enforces.
+type SecurityScheme struct {
+	Middleware string
+	Scheme     string
+	Scopes     []string
+	Definition Security
 }
 
 type DefinitionProperty struct {
-	Type        string      `json:"type,omitempty" yaml:",omitempty"`
-	Format      string      `json:"format,omitempty" yaml:",omitempty"`
-	Description string      `json:"description,omitempty" yaml:",omitempty"`
-	Enum        []string    `json:"enum,omitempty" yaml:",omitempty"`
-	Ref         string      `json:"$ref,omitempty" yaml:"oneOf,omitempty"`
-	Default     interface{} `json:"default,omitempty" yaml:",omitempty"`
+	Type                 string                        `json:"type,omitempty" yaml:",omitempty"`
+	Format               string                        `json:"format,omitempty" yaml:",omitempty"`
+	Description          string                        `json:"description,omitempty" yaml:",omitempty"`
+	Enum                 []interface{}                 `json:"enum,omitempty" yaml:",omitempty"`
+	EnumVarNames         []string                      `json:"x-enum-varnames,omitempty" yaml:"x-enum-varnames,omitempty"`
+	Ref                  string                        `json:"$ref,omitempty" yaml:"$ref,omitempty"`
+	AllOf                []DefinitionProperty          `json:"allOf,omitempty" yaml:"allOf,omitempty"`
+	Default              interface{}                   `json:"default,omitempty" yaml:",omitempty"`
+	Required             []string                      `json:"required,omitempty" yaml:",omitempty"`
+	Properties           map[string]DefinitionProperty `json:"properties,omitempty" yaml:",omitempty"`
+	Items                *DefinitionItem               `json:"items,omitempty" yaml:",omitempty"`
+	AdditionalProperties *DefinitionProperty           `json:"additionalProperties,omitempty" yaml:"additionalProperties,omitempty"`
+	Nullable             bool                          `json:"x-nullable,omitempty" yaml:"x-nullable,omitempty"`
+	Pattern              string                        `json:"pattern,omitempty" yaml:",omitempty"`
+	MinLength            *int64                        `json:"minLength,omitempty" yaml:"minLength,omitempty"`
+	MaxLength            *int64                        `json:"maxLength,omitempty" yaml:"maxLength,omitempty"`
+	Minimum              *float64                      `json:"minimum,omitempty" yaml:",omitempty"`
+	Maximum              *float64                      `json:"maximum,omitempty" yaml:",omitempty"`
+	ExclusiveMinimum     bool                          `json:"exclusiveMinimum,omitempty" yaml:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum     bool                          `json:"exclusiveMaximum,omitempty" yaml:"exclusiveMaximum,omitempty"`
+	MinItems             *int64                        `json:"minItems,omitempty" yaml:"minItems,omitempty"`
+	MaxItems             *int64                        `json:"maxItems,omitempty" yaml:"maxItems,omitempty"`
+	Example              interface{}                   `json:"example,omitempty" yaml:",omitempty"`
+	ReadOnly             bool                          `json:"readOnly,omitempty" yaml:"readOnly,omitempty"`
+	WriteOnly            bool                          `json:"x-writeOnly,omitempty" yaml:"x-writeOnly,omitempty"`
+	Deprecated           bool                          `json:"x-deprecated,omitempty" yaml:"x-deprecated,omitempty"`
+	Association          string                        `json:"x-association,omitempty" yaml:"x-association,omitempty"`
+	ForeignKey           string                        `json:"x-foreign-key,omitempty" yaml:"x-foreign-key,omitempty"`
+	JoinTable            string                        `json:"x-join-table,omitempty" yaml:"x-join-table,omitempty"`
 }
 
 type Xml struct {
@@ -152,16 +179,24 @@ type Xml struct {
 }
 
 type DefinitionItem struct {
-	Type string `json:"type,omitempty" yaml:",omitempty"`
-	Ref  string `json:"$ref,omitempty" yaml:",omitempty"`
+	Type                 string              `json:"type,omitempty" yaml:",omitempty"`
+	Format               string              `json:"format,omitempty" yaml:",omitempty"`
+	Enum                 []interface{}       `json:"enum,omitempty" yaml:",omitempty"`
+	EnumVarNames         []string            `json:"x-enum-varnames,omitempty" yaml:"x-enum-varnames,omitempty"`
+	Ref                  string              `json:"$ref,omitempty" yaml:"$ref,omitempty"`
+	AllOf                []DefinitionItem    `json:"allOf,omitempty" yaml:"allOf,omitempty"`
+	Items                *DefinitionItem     `json:"items,omitempty" yaml:",omitempty"`
+	AdditionalProperties *DefinitionProperty `json:"additionalProperties,omitempty" yaml:"additionalProperties,omitempty"`
+	Nullable             bool                `json:"x-nullable,omitempty" yaml:"x-nullable,omitempty"`
 }
 
 type Definition struct {
-	Type       string                        `json:"type,omitempty" yaml:",omitempty"`
-	Required   []string                      `json:"required,omitempty" yaml:",omitempty"`
-	Properties map[string]DefinitionProperty `json:"properties,omitempty" yaml:",omitempty"`
-	Xml        *Xml                          `json:"xml,omitempty" yaml:",omitempty"`
-	Items      *DefinitionItem               `json:"items,omitempty" yaml:",omitempty"`
+	Type        string                        `json:"type,omitempty" yaml:",omitempty"`
+	Description string                        `json:"description,omitempty" yaml:",omitempty"`
+	Required    []string                      `json:"required,omitempty" yaml:",omitempty"`
+	Properties  map[string]DefinitionProperty `json:"properties,omitempty" yaml:",omitempty"`
+	Xml         *Xml                          `json:"xml,omitempty" yaml:",omitempty"`
+	Items       *DefinitionItem               `json:"items,omitempty" yaml:",omitempty"`
 }
 
 type Swagger struct {
@@ -179,6 +214,38 @@ type Swagger struct {
 
 type Generator struct {
 	SwaggerFile string
+	Security    []SecurityScheme
+	// Types are the configured mappings of Go types to schemas
+	Types []TypeMapping
+	// EnumVarNames adds the names of enum constants as x-enum-varnames
+	EnumVarNames bool
+	// OpenAPI is the OpenAPI version to generate instead of Swagger 2.0
+	OpenAPI string
+	// Servers are the base URLs of OpenAPI documents
+	Servers []Server
+	// Webhooks are the requests the API sends, OpenAPI 3.1 only
+	Webhooks []Webhook
+	// JSONSchema writes the definitions as a JSON Schema instead of an API
+	// document
+	JSONSchema bool
+
+	types      typeRegistry
+	enums      map[string]parser.Enum
+	underlying map[string]string
+
+	// definitions maps qualified Go types to their definition key
+	definitions map[string]string
+	// definitionTypes maps qualified Go types to their definition
+	definitionTypes map[string]parser.Definition
+	// definitionNames maps the names of types to their definitions
+	definitionNames map[string][]parser.Definition
+	// errorSchemas are the schemas rendered by custom app.ErrorHandlers
+	errorSchemas map[int]*Schema
+	// errorResponse is set when an operation references ERROR_DEFINITION
+	errorResponse bool
+
+	// Diagnostics are the parts of the project that couldn't be described
+	Diagnostics parser.Diagnostics
 }
 
 func NewGenerator(filePath string) *Generator {
@@ -187,193 +254,17 @@ func NewGenerator(filePath string) *Generator {
 	}
 }
 
-func endpointGetPost(def parser.Definition) map[string]Endpoint {
-	res := map[string]Endpoint{}
-	res["get"] = Endpoint{
-		Tags:        []string{def.Name.PluralUnder()},
-		Summary:     "Get a list of " + def.Name.PluralCamel(),
-		OperationID: "get" + def.Name.PluralCamel(),
-		Produces:    []string{APP_JSON},
-		Responses: map[string]Response{
-			"200": Response{
-				Schema: &ResponseSchema{
-					Type: "array",
-					Items: Schema{
-						Ref: "#/definitions/" + def.Name.CamelSingular(),
-					},
-				},
-			},
-		},
-	}
-
-	// param := Parameter{
-	// 	In: "body",
-	// 	Schema: &Schema{
-	// 		Type:       "object",
-	// 		Properties: map[string]Property{},
-	// 	},
-	// }
-	// for _, prop := range def.Properties {
-	// 	if prop.Name == "ID" {
-	// 		continue
-	// 	}
-	// 	param.Schema.Required = append(param.Schema.Required, prop.Name.VarNameUnderscore())
-	// 	param.Schema.Properties[prop.Name.VarNameUnderscore()] = Property{
-	// 		Type: swaggerType(prop.Type),
-	// 	}
-	// }
-
-	res["post"] = Endpoint{
-		Tags:        []string{def.Name.Lower()},
-		Summary:     "Create a new " + def.Name.Camel(),
-		OperationID: "add" + def.Name.Camel(),
-		Consumes:    []string{APP_JSON},
-		Produces:    []string{APP_JSON},
-		// RequestBody: RequestBody{
-		// 	Required: true,
-		// 	Content: map[string]Body{
-		// 		APP_JSON: Body{
-		// 			Schema: BodySchema{
-		// 				Ref: "#/definitions/" + def.Name,
-		// 			},
-		// 		},
-		// 	},
-		// },
-		Parameters: []Parameter{
-			Parameter{
-				In:          "body",
-				Description: def.Name.CamelSingular() + " that needs to be added",
-				Required:    true,
-				Schema: &Schema{
-					Ref: "#/definitions/" + def.Name.Camel(),
-				},
-			},
-		},
-		Responses: map[string]Response{
-			"201": Response{
-				Schema: &ResponseSchema{
-					Type: "object",
-					Items: Schema{
-						Ref: "#/definitions/" + def.Name.CamelSingular(),
-					},
-				},
-			},
-		},
-	}
-	return res
-}
-
-func modifyEndpoint(def parser.Definition) map[string]Endpoint {
-	res := map[string]Endpoint{}
-	res["get"] = Endpoint{
-		Tags:        []string{def.Name.Lower()},
-		Summary:     "Get a " + def.Name.Camel() + " by ID",
-		OperationID: "get" + def.Name.CamelSingular(),
-		Consumes:    []string{APP_JSON},
-		Produces:    []string{APP_JSON},
-		Parameters: []Parameter{
-			Parameter{
-				Name:     "id",
-				In:       "path",
-				Required: true,
-				Type:     "integer",
-			},
-		},
-		Responses: map[string]Response{
-			"200": Response{
-				Schema: &ResponseSchema{
-					Type: "object",
-					Items: Schema{
-						Ref: "#/definitions/" + def.Name.CamelSingular(),
-					},
-				},
-			},
-			"404": Response{Description: "Not found"},
-		},
-	}
-
-	res["delete"] = Endpoint{
-		Tags:        []string{def.Name.Lower()},
-		Summary:     "delete a " + def.Name.Camel() + " by ID",
-		OperationID: "destroy" + def.Name.CamelSingular(),
-		Consumes:    []string{APP_JSON},
-		Produces:    []string{APP_JSON},
-		Parameters: []Parameter{
-			Parameter{
-				Name:     "id",
-				In:       "path",
-				Required: true,
-				Type:     "integer",
-			},
-		},
-		Responses: map[string]Response{
-			"400": Response{Description: "Invalid ID"},
-			"404": Response{Description: "Not found"},
-		},
+func (g *Generator) Generate(parser *parser.Parser, exportAsYaml bool) error {
+	switch g.OpenAPI {
+	case "", SWAGGER_VERSION, "3.0", "3.1":
+	default:
+		return fmt.Errorf("unsupported OpenAPI version %s", g.OpenAPI)
 	}
-
-	// param := Parameter{
-	// 	In: "body",
-	// 	Schema: &Schema{
-	// 		Type:       "object",
-	// 		Properties: map[string]Property{},
-	// 	},
-	// }
-	// for _, prop := range def.Properties {
-	// 	if prop.Name == "ID" {
-	// 		continue
-	// 	}
-	// 	param.Schema.Required = append(param.Schema.Required, prop.Name.VarNameUnderscore())
-	// 	param.Schema.Properties[prop.Name.VarNameUnderscore()] = Property{
-	// 		Type: swaggerType(prop.Type),
-	// 	}
-	// }
-
-	res["put"] = Endpoint{
-		Tags:        []string{def.Name.Lower()},
-		Summary:     "Update a " + def.Name.Camel() + " with ID",
-		OperationID: "put" + def.Name.CamelSingular(),
-		Consumes:    []string{APP_JSON},
-		Produces:    []string{APP_JSON},
-		Parameters: []Parameter{
-			Parameter{
-				Name:     "id",
-				In:       "path",
-				Required: true,
-				Type:     "integer",
-			},
-			Parameter{
-				In: "body",
-				Schema: &Schema{
-					Ref: "#/definitions/" + def.Name.Camel(),
-				},
-			},
-		},
-		// RequestBody: RequestBody{
-		// 	Required: true,
-		// 	Content: map[string]Body{
-		// 		APP_JSON: Body{
-		// 			Schema: BodySchema{
-		// 				Ref: "#/definitions/" + def.Name,
-		// 			},
-		// 		},
-		// 	},
-		// },
-		Responses: map[string]Response{
-			"201": Response{
-				Schema: &ResponseSchema{
-					Type: "object",
-					Items: Schema{
-						Ref: "#/definitions/" + def.Name.CamelSingular(),
-					},
-				},
-			},
-		},
+	// the JSON Schema replaces the API document
+	if g.JSONSchema && g.OpenAPI != "" {
+		return fmt.Errorf("a JSON Schema can't be generated as OpenAPI %s", g.OpenAPI)
 	}
-	return res
-}
 
-func (g *Generator) Generate(parser *parser.Parser, exportAsYaml bool) error {
 	swaggerFile := Swagger{
 		Swagger: SWAGGER_VERSION,
 	}
@@ -383,37 +274,87 @@ func (g *Generator) Generate(parser *parser.Parser, exportAsYaml bool) error {
 	swaggerFile.Paths = map[string]map[string]Endpoint{}
 	swaggerFile.Definitions = map[string]Definition{}
 
-	for _, def := range parser.Definitions {
+	if len(g.Security) > 0 {
+		swaggerFile.SecurityDefinitions = map[string]Security{}
+		for _, scheme := range g.Security {
+			swaggerFile.SecurityDefinitions[scheme.Scheme] = scheme.Definition
+		}
+	}
 
-		// paths
-		resourceName := def.Name.PluralUnder()
-		swaggerFile.Paths["/"+resourceName] = endpointGetPost(def)
-		swaggerFile.Paths["/"+resourceName+"/{id}"] = modifyEndpoint(def)
+	g.types = newTypeRegistry(g.Types)
+	g.enums = parser.Enums
+	g.underlying = parser.Underlying
 
-		// model definitions
-		definition := Definition{
-			Type: "object",
-		}
-		definition.Properties = map[string]DefinitionProperty{}
-		for _, prop := range def.Properties {
-			if isSimpleType(prop.Type) {
-				definition.Properties[prop.Name.VarNameUnderscore()] = DefinitionProperty{
-					Type:   swaggerType(prop.Type),
-					Format: swaggerFormat(prop.Type),
+	g.definitionKeys(parser.Definitions)
+
+	g.errorSchemas = map[int]*Schema{}
+	for status, name := range parser.ErrorHandlers {
+		if handler, ok := parser.Handlers[name]; ok {
+			for _, res := range handler.Responses {
+				if res.Type != "" {
+					g.errorSchemas[status] = g.typeSchema(res.Type)
+					break
 				}
-			} else {
-				fmt.Printf("skipping %s for now\n", prop.Type)
 			}
 		}
-		swaggerFile.Definitions[def.Name.CamelSingular()] = definition
+	}
+
+	operationIDs := map[string]bool{}
+	for _, route := range parser.Routes {
+		routePath := swaggerPath(route.Path)
+		if _, ok := swaggerFile.Paths[routePath]; !ok {
+			swaggerFile.Paths[routePath] = map[string]Endpoint{}
+		}
+		swaggerFile.Paths[routePath][strings.ToLower(route.Method)] = g.routeEndpoint(route, parser.Handlers[route.Handler], operationIDs)
+	}
+
+	if g.errorResponse {
+		swaggerFile.Definitions[ERROR_DEFINITION] = errorDefinition()
+	}
+
+	for _, def := range parser.Definitions {
+		key, _ := g.definitionKey(def.GoType())
+		if def.Items != "" {
+			// slice definitions
+			items, ok := g.typeProperty(def.Items)
+			if !ok {
+				g.Diagnostics.Warnf(def.Position, "skipping definition %s of unknown items type %s", def.Name, def.Items)
+				continue
+			}
+			swaggerFile.Definitions[key] = Definition{
+				Type:        "array",
+				Description: def.Description,
+				Items:       definitionItem(items),
+			}
+			continue
+		}
+
+		// model definitions
+		definition := Definition{
+			Type:        "object",
+			Description: def.Description,
+		}
+		definition.Properties, definition.Required = g.objectProperties(def.Properties)
+		swaggerFile.Definitions[key] = definition
+	}
+
+	var document interface{} = swaggerFile
+	switch {
+	case g.JSONSchema:
+		document = jsonSchemaDefs(swaggerFile)
+	case g.OpenAPI == "" || g.OpenAPI == SWAGGER_VERSION:
+	case g.OpenAPI == "3.0":
+		document = g.openAPI(swaggerFile)
+	case g.OpenAPI == "3.1":
+		document = g.openAPI31(swaggerFile)
 	}
 
 	var swaggerContent []byte
 	var err error
 	if exportAsYaml {
-		swaggerContent, err = yaml.Marshal(swaggerFile)
+		swaggerContent, err = yaml.Marshal(document)
 	} else {
-		swaggerContent, err = json.MarshalIndent(swaggerFile, "", "  ")
+		swaggerContent, err = json.MarshalIndent(document, "", "  ")
 	}
 	if err != nil {
 		return err
@@ -435,44 +376,332 @@ func (g *Generator) Generate(parser *parser.Parser, exportAsYaml bool) error {
 	return nil
 }
 
-func isSimpleType(goType string) bool {
-	types := map[string]string{}
-	types["int"] = "integer"
-	types["int32"] = "integer"
-	types["int64"] = "integer"
-	types["&{time Time}"] = "string"
-	types["string"] = "string"
-	types["bool"] = "boolean"
-	types["float"] = "number"
-	types["float64"] = "number"
-	_, ok := types[goType]
-	return ok
+// definitionKeys names the definitions after their types. Types of the
+// same name in different packages are prefixed with as many elements of
+// their import paths as it takes to tell them apart, e.g. models.api.Account
+// and dto.api.Account.
+func (g *Generator) definitionKeys(definitions []parser.Definition) {
+	keys := make([]string, len(definitions))
+	depths := make([]int, len(definitions))
+	for {
+		count := map[string]int{}
+		for i, def := range definitions {
+			keys[i] = prefixedName(def, depths[i])
+			count[keys[i]]++
+		}
+		lengthened := false
+		for i, def := range definitions {
+			if count[keys[i]] > 1 && depths[i] < len(strings.Split(def.PkgPath, "/")) {
+				depths[i]++
+				lengthened = true
+			}
+		}
+		if !lengthened {
+			break
+		}
+	}
+
+	g.definitions = map[string]string{}
+	g.definitionTypes = map[string]parser.Definition{}
+	g.definitionNames = map[string][]parser.Definition{}
+	clashes := map[string]parser.Definition{}
+	for i, def := range definitions {
+		key := keys[i]
+		if other, ok := clashes[key]; ok {
+			g.Diagnostics.Errorf(def.Position, "definitions of %s and %s have the same key %s", def.GoType(), other.GoType(), key)
+		}
+		clashes[key] = def
+		g.definitions[def.GoType()] = key
+		g.definitionTypes[def.GoType()] = def
+		g.definitionNames[string(def.Name)] = append(g.definitionNames[string(def.Name)], def)
+	}
 }
 
-func swaggerFormat(goType string) string {
-	format := map[string]string{}
-	format["&{time Time}"] = "date-time"
-	if v, ok := format[goType]; ok {
-		return v
+// prefixedName returns the name of a definition prefixed with the last
+// depth elements of its import path.
+func prefixedName(def parser.Definition, depth int) string {
+	elements := strings.Split(def.PkgPath, "/")
+	if depth > len(elements) {
+		depth = len(elements)
 	}
-	return goType
+	return strings.Join(append(elements[len(elements)-depth:], string(def.Name)), ".")
 }
 
-func swaggerType(goType string) string {
-	types := map[string]string{}
-	types["[]"] = "array"
-	types["int"] = "integer"
-	types["int32"] = "integer"
-	types["int64"] = "integer"
-	types["&{time Time}"] = "string"
-	types["string"] = "string"
-	types["bool"] = "boolean"
-	types["float"] = "number"
-	types["float64"] = "number"
+// definitionKey returns the key of the definition of a Go type.
+func (g *Generator) definitionKey(goType string) (string, bool) {
+	def, ok := g.definition(goType)
+	if !ok {
+		return "", false
+	}
+	return g.definitions[def.GoType()], true
+}
 
-	if v, ok := types[goType]; ok {
-		return v
+// definition returns the parsed definition of a Go type.
+func (g *Generator) definition(goType string) (parser.Definition, bool) {
+	if def, ok := g.definitionTypes[goType]; ok {
+		return def, true
+	}
+	// names only identify types of unknown packages and the packages of
+	// projects without go.mod
+	var found []parser.Definition
+	for _, def := range g.definitionNames[parser.UnqualifiedName(goType)] {
+		if pkgPath := parser.PackagePath(goType); pkgPath == "" || parser.SamePackage(pkgPath, def.PkgPath) {
+			found = append(found, def)
+		}
+	}
+	if len(found) != 1 {
+		return parser.Definition{}, false
 	}
+	return found[0], true
+}
 
-	return goType
+// objectProperties returns the schemas of struct properties and the json
+// names of the required ones.
+func (g *Generator) objectProperties(props []parser.Property) (map[string]DefinitionProperty, []string) {
+	properties := map[string]DefinitionProperty{}
+	var required []string
+	for _, prop := range props {
+		if prop.JSONHidden {
+			continue
+		}
+		property, ok := g.definitionProperty(prop)
+		if !ok {
+			g.Diagnostics.Warnf(prop.Position, "skipping property %s of unknown type %s", prop.Name, prop.Type)
+			continue
+		}
+		if prop.Description != "" {
+			property.Description = prop.Description
+		}
+		applyConstraints(&property, prop.Constraints)
+		applyOverrides(&property, prop.Overrides)
+		if prop.Association != nil {
+			// associations are only filled when eager loaded
+			property.Association = prop.Association.Kind
+			property.ForeignKey = prop.Association.ForeignKey
+			property.JoinTable = prop.Association.JoinTable
+		}
+		properties[prop.JSONName] = allOfRef(property)
+		// read only properties and associations must not be required
+		if !property.ReadOnly && prop.Association == nil && (!prop.OmitEmpty || prop.Constraints.Required) {
+			required = append(required, prop.JSONName)
+		}
+	}
+	return properties, required
+}
+
+func (g *Generator) definitionProperty(prop parser.Property) (DefinitionProperty, bool) {
+	switch {
+	case prop.Fields != nil:
+		property := DefinitionProperty{
+			Type: "object",
+		}
+		property.Properties, property.Required = g.objectProperties(prop.Fields)
+		return property, true
+	case prop.AsString && isBasicType(strings.TrimPrefix(prop.Type, "*")):
+		return DefinitionProperty{
+			Type:     "string",
+			Nullable: strings.HasPrefix(prop.Type, "*"),
+		}, true
+	}
+	return g.typeProperty(prop.Type)
+}
+
+// typeProperty returns the schema of a Go type expression.
+func (g *Generator) typeProperty(goType string) (DefinitionProperty, bool) {
+	if strings.HasPrefix(goType, "*") {
+		property, ok := g.typeProperty(goType[1:])
+		property.Nullable = true
+		return property, ok
+	}
+
+	if m, ok := g.types.lookup(goType); ok {
+		if m.As != "" {
+			property, ok := g.typeProperty(m.As)
+			property.Nullable = property.Nullable || m.Nullable
+			return property, ok
+		}
+		return DefinitionProperty{
+			Type:     m.Type,
+			Format:   m.Format,
+			Nullable: m.Nullable,
+		}, true
+	}
+
+	if enum, ok := g.enums[goType]; ok {
+		property, ok := g.typeProperty(enum.Underlying)
+		for _, value := range enum.Values {
+			property.Enum = append(property.Enum, value.Value)
+			if g.EnumVarNames {
+				property.EnumVarNames = append(property.EnumVarNames, value.Name)
+			}
+		}
+		return property, ok
+	}
+
+	if elem, ok := sliceElem(goType); ok {
+		items, ok := g.typeProperty(elem)
+		if !ok {
+			return DefinitionProperty{}, false
+		}
+		return DefinitionProperty{Type: "array", Items: definitionItem(items)}, true
+	}
+
+	if strings.HasPrefix(goType, "map[") {
+		values, ok := g.typeProperty(mapValueType(goType))
+		if !ok {
+			return DefinitionProperty{}, false
+		}
+		values = allOfRef(values)
+		return DefinitionProperty{Type: "object", AdditionalProperties: &values}, true
+	}
+
+	if key, ok := g.definitionKey(goType); ok {
+		return DefinitionProperty{Ref: "#/definitions/" + key}, true
+	}
+
+	// named types like type Cents int64 without constants
+	if underlying, ok := g.underlying[goType]; ok {
+		return g.typeProperty(underlying)
+	}
+	return DefinitionProperty{}, false
+}
+
+// applyConstraints adds the validations of a model to a property.
+func applyConstraints(property *DefinitionProperty, c parser.Constraints) {
+	if c.Format != "" {
+		property.Format = c.Format
+	}
+	if c.Enum != nil {
+		property.Enum = nil
+		property.EnumVarNames = nil
+		for _, value := range c.Enum {
+			property.Enum = append(property.Enum, value)
+		}
+	}
+	property.Pattern = c.Pattern
+	property.MinLength = c.MinLength
+	property.MaxLength = c.MaxLength
+	property.Minimum = c.Minimum
+	property.Maximum = c.Maximum
+	property.ExclusiveMinimum = c.ExclusiveMinimum
+	property.ExclusiveMaximum = c.ExclusiveMaximum
+	property.MinItems = c.MinItems
+}
+
+// applyOverrides sets the schema details of a swagger struct tag.
+func applyOverrides(property *DefinitionProperty, o parser.Overrides) {
+	if o.Description != "" {
+		property.Description = o.Description
+	}
+	if o.Format != "" {
+		property.Format = o.Format
+	}
+	if o.Example != "" {
+		property.Example = exampleValue(property.Type, o.Example)
+	}
+	property.ReadOnly = property.ReadOnly || o.ReadOnly
+	property.WriteOnly = property.WriteOnly || o.WriteOnly
+	property.Deprecated = property.Deprecated || o.Deprecated
+
+	// min and max bound whatever the type has to bound
+	switch property.Type {
+	case "string":
+		if o.Min != nil {
+			property.MinLength = parser.Int64Ptr(int64(*o.Min))
+		}
+		if o.Max != nil {
+			property.MaxLength = parser.Int64Ptr(int64(*o.Max))
+		}
+	case "array":
+		if o.Min != nil {
+			property.MinItems = parser.Int64Ptr(int64(*o.Min))
+		}
+		if o.Max != nil {
+			property.MaxItems = parser.Int64Ptr(int64(*o.Max))
+		}
+	default:
+		if o.Min != nil {
+			property.Minimum = o.Min
+			property.ExclusiveMinimum = false
+		}
+		if o.Max != nil {
+			property.Maximum = o.Max
+			property.ExclusiveMaximum = false
+		}
+	}
+}
+
+// exampleValue converts an example to the type of its schema, examples
+// that don't parse stay strings.
+func exampleValue(schemaType string, example string) interface{} {
+	switch schemaType {
+	case "integer":
+		if i, err := strconv.ParseInt(example, 10, 64); err == nil {
+			return i
+		}
+	case "number":
+		if f, err := strconv.ParseFloat(example, 64); err == nil {
+			return f
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(example); err == nil {
+			return b
+		}
+	}
+	return example
+}
+
+func definitionItem(property DefinitionProperty) *DefinitionItem {
+	item := &DefinitionItem{
+		Type:                 property.Type,
+		Format:               property.Format,
+		Enum:                 property.Enum,
+		EnumVarNames:         property.EnumVarNames,
+		Ref:                  property.Ref,
+		Items:                property.Items,
+		AdditionalProperties: property.AdditionalProperties,
+		Nullable:             property.Nullable,
+	}
+	if item.Ref != "" && !reflect.DeepEqual(*item, DefinitionItem{Ref: item.Ref}) {
+		item.AllOf = []DefinitionItem{{Ref: item.Ref}}
+		item.Ref = ""
+	}
+	return item
+}
+
+// allOfRef moves a $ref with siblings like a description into an allOf,
+// the siblings of $ref are ignored.
+func allOfRef(property DefinitionProperty) DefinitionProperty {
+	if property.Ref == "" || reflect.DeepEqual(property, DefinitionProperty{Ref: property.Ref}) {
+		return property
+	}
+	property.AllOf = []DefinitionProperty{{Ref: property.Ref}}
+	property.Ref = ""
+	return property
+}
+
+// sliceElem returns T of []T and [N]T.
+func sliceElem(goType string) (string, bool) {
+	if !strings.HasPrefix(goType, "[") {
+		return "", false
+	}
+	end := strings.Index(goType, "]")
+	if end < 0 {
+		return "", false
+	}
+	return goType[end+1:], true
+}
+
+// errorDefinition describes buffalo.ErrorResponse, which the default error
+// handler renders.
+func errorDefinition() Definition {
+	return Definition{
+		Type:     "object",
+		Required: []string{"error", "code"},
+		Properties: map[string]DefinitionProperty{
+			"error": DefinitionProperty{Type: "string"},
+			"trace": DefinitionProperty{Type: "string"},
+			"code":  DefinitionProperty{Type: "integer", Format: "int32"},
+		},
+	}
 }
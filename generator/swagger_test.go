@@ -0,0 +1,366 @@
+package generator
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/fsuhrau/buffalo-swagger/parser"
+)
+
+// fixture is a buffalo project using stubs of the buffalo packages.
+const fixture = "../testdata/app"
+
+var (
+	fixtureOnce   sync.Once
+	fixtureParser *parser.Parser
+	fixtureErr    error
+)
+
+// parseFixture parses the fixture project once, generating documents only
+// reads the parser.
+func parseFixture(t *testing.T) *parser.Parser {
+	t.Helper()
+	fixtureOnce.Do(func() {
+		fixtureParser = parser.NewParser(fixture)
+		_, fixtureErr = fixtureParser.ParseProject()
+	})
+	if fixtureErr != nil {
+		t.Fatalf("ParseProject() error = %v", fixtureErr)
+	}
+	return fixtureParser
+}
+
+// generate writes the document of the fixture project with a generator
+// configured by configure and returns it decoded.
+func generate(t *testing.T, configure func(g *Generator)) map[string]interface{} {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "swagger.json")
+	g := NewGenerator(file)
+	if configure != nil {
+		configure(g)
+	}
+	if err := g.Generate(parseFixture(t), false); err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	for _, d := range g.Diagnostics {
+		t.Errorf("unexpected diagnostic %s", d)
+	}
+
+	content, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var document map[string]interface{}
+	if err := json.Unmarshal(content, &document); err != nil {
+		t.Fatal(err)
+	}
+	return document
+}
+
+// documentTest expects the value at the keys of a document to encode as
+// the JSON want.
+type documentTest struct {
+	keys []string
+	want string
+}
+
+func (tt documentTest) run(t *testing.T, document map[string]interface{}) {
+	t.Run(strings.Join(tt.keys, " "), func(t *testing.T) {
+		var value interface{} = document
+		for _, key := range tt.keys {
+			object, ok := value.(map[string]interface{})
+			if !ok {
+				t.Fatalf("%s isn't an object", key)
+			}
+			if value, ok = object[key]; !ok {
+				t.Fatalf("%s not found", key)
+			}
+		}
+		var want interface{}
+		if err := json.Unmarshal([]byte(tt.want), &want); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(value, want) {
+			got, _ := json.Marshal(value)
+			t.Errorf("got %s, want %s", got, tt.want)
+		}
+	})
+}
+
+func runDocumentTests(t *testing.T, document map[string]interface{}, tests []documentTest) {
+	t.Helper()
+	for _, tt := range tests {
+		tt.run(t, document)
+	}
+}
+
+func TestGenerateRoutes(t *testing.T) {
+	runDocumentTests(t, generate(t, nil), []documentTest{
+		{[]string{"swagger"}, `"2.0"`},
+		{[]string{"paths", "/", "get", "operationId"}, `"homeHandler"`},
+	})
+}
+
+func TestGenerateResources(t *testing.T) {
+	runDocumentTests(t, generate(t, nil), []documentTest{
+		{[]string{"paths", "/widgets", "get", "tags"}, `["widgets"]`},
+		{[]string{"paths", "/widgets", "get", "operationId"}, `"widgetsResourceList"`},
+		{[]string{"paths", "/widgets/{widget_id}", "put", "operationId"}, `"widgetsResourceUpdate"`},
+		// PUT and PATCH share the handler
+		{[]string{"paths", "/widgets/{widget_id}", "patch", "operationId"}, `"widgetsResourceUpdatePatch"`},
+	})
+}
+
+func TestGenerateGroups(t *testing.T) {
+	runDocumentTests(t, generate(t, nil), []documentTest{
+		{[]string{"paths", "/api/v1/tags/{tag_id}/widgets", "get", "parameters"}, `[
+			{"in": "path", "name": "tag_id", "required": true, "type": "string"}
+		]`},
+		{[]string{"paths", "/api/v1/tags", "get", "tags"}, `["tags"]`},
+	})
+}
+
+func TestGenerateResponses(t *testing.T) {
+	runDocumentTests(t, generate(t, nil), []documentTest{
+		{[]string{"paths", "/", "get", "produces"}, `["text/plain"]`},
+		{[]string{"paths", "/", "get", "responses"}, `{"200": {"description": "OK", "schema": {"type": "string"}}}`},
+		// r.Auto renders JSON or XML
+		{[]string{"paths", "/widgets/{widget_id}", "get", "produces"}, `["application/json", "application/xml"]`},
+		{[]string{"paths", "/widgets", "get", "responses"}, `{
+			"200": {"description": "OK", "schema": {"$ref": "#/definitions/Widgets"}}
+		}`},
+		{[]string{"paths", "/widgets/{widget_id}", "delete", "responses", "204"}, `{"description": "No Content"}`},
+	})
+}
+
+func TestGenerateBind(t *testing.T) {
+	runDocumentTests(t, generate(t, nil), []documentTest{
+		// forms can't be described next to the body parameter
+		{[]string{"paths", "/widgets", "post", "consumes"}, `["application/json", "application/xml"]`},
+		{[]string{"paths", "/widgets", "post", "parameters"}, `[
+			{"in": "body", "name": "body", "required": true, "schema": {"$ref": "#/definitions/Widget"}}
+		]`},
+	})
+}
+
+func TestGenerateParams(t *testing.T) {
+	runDocumentTests(t, generate(t, nil), []documentTest{
+		{[]string{"paths", "/api/v1/admin/stats", "get", "parameters"}, `[
+			{"in": "query", "name": "days", "type": "integer", "format": "int64"}
+		]`},
+		{[]string{"paths", "/widgets/{widget_id}", "get", "parameters"}, `[
+			{"in": "path", "name": "widget_id", "required": true, "type": "string", "format": "uuid"}
+		]`},
+	})
+}
+
+func TestGenerateErrors(t *testing.T) {
+	runDocumentTests(t, generate(t, nil), []documentTest{
+		// the 500 is rendered by the custom error handler
+		{[]string{"paths", "/widgets", "post", "responses"}, `{
+			"201": {"description": "Created", "schema": {"$ref": "#/definitions/Widget"}},
+			"422": {"description": "Unprocessable Entity", "schema": {"$ref": "#/definitions/APIError"}},
+			"500": {"description": "Internal Server Error", "schema": {"$ref": "#/definitions/APIError"}}
+		}`},
+		{[]string{"paths", "/widgets/{widget_id}", "delete", "responses", "404"}, `{
+			"description": "Not Found", "schema": {"$ref": "#/definitions/ErrorResponse"}
+		}`},
+		{[]string{"definitions", "ErrorResponse", "required"}, `["error", "code"]`},
+	})
+}
+
+func TestGenerateSecurity(t *testing.T) {
+	document := generate(t, func(g *Generator) {
+		g.Security = []SecurityScheme{
+			{Scheme: "bearer", Middleware: "Authorize", Definition: Security{Type: "apiKey", Name: "Authorization", In: "header"}},
+			{Scheme: "api_key", Middleware: "APIKey", Definition: Security{Type: "apiKey", Name: "X-API-Key", In: "header"}},
+		}
+	})
+	runDocumentTests(t, document, []documentTest{
+		{[]string{"securityDefinitions", "bearer"}, `{"type": "apiKey", "name": "Authorization", "in": "header"}`},
+		{[]string{"paths", "/widgets", "get", "security"}, `[{"bearer": []}]`},
+		{[]string{"paths", "/api/v1/status", "get", "security"}, `[{"bearer": [], "api_key": []}]`},
+	})
+
+	// Authorize is skipped for HomeHandler and WidgetsResource.Show
+	paths := document["paths"].(map[string]interface{})
+	for _, routePath := range []string{"/", "/widgets/{widget_id}"} {
+		operation := paths[routePath].(map[string]interface{})["get"].(map[string]interface{})
+		if security, ok := operation["security"]; ok {
+			t.Errorf("GET %s security = %v, want none", routePath, security)
+		}
+	}
+}
+
+func TestGenerateEmbedded(t *testing.T) {
+	runDocumentTests(t, generate(t, nil), []documentTest{
+		// promoted from the embedded Timestamps
+		{[]string{"definitions", "Widget", "properties", "created_at"}, `{"type": "string", "format": "date-time"}`},
+		{[]string{"definitions", "Widget", "properties", "dimensions"}, `{"$ref": "#/definitions/Dimensions"}`},
+	})
+}
+
+func TestGenerateCollections(t *testing.T) {
+	runDocumentTests(t, generate(t, nil), []documentTest{
+		{[]string{"paths", "/api/v1/admin/stats", "get", "responses", "200", "schema"}, `{
+			"type": "object", "additionalProperties": {"type": "integer", "format": "int64"}
+		}`},
+		{[]string{"definitions", "Widget", "properties", "tags", "type"}, `"array"`},
+		{[]string{"definitions", "Widget", "properties", "tags", "items"}, `{"$ref": "#/definitions/Tag"}`},
+	})
+}
+
+func TestGenerateNamedTypes(t *testing.T) {
+	runDocumentTests(t, generate(t, nil), []documentTest{
+		// type Cents int64
+		{[]string{"definitions", "Widget", "properties", "price", "type"}, `"integer"`},
+		{[]string{"definitions", "Widget", "properties", "price", "format"}, `"int64"`},
+	})
+}
+
+func TestGenerateNullable(t *testing.T) {
+	runDocumentTests(t, generate(t, nil), []documentTest{
+		{[]string{"definitions", "Widget", "properties", "description"}, `{"type": "string", "x-nullable": true}`},
+		{[]string{"definitions", "Widget", "properties", "retired"}, `{"type": "string", "format": "date-time", "x-nullable": true}`},
+		{[]string{"definitions", "Widget", "properties", "owner", "x-nullable"}, `true`},
+	})
+}
+
+func TestGenerateTypeMappings(t *testing.T) {
+	runDocumentTests(t, generate(t, nil), []documentTest{
+		{[]string{"definitions", "Widget", "properties", "id"}, `{"type": "string", "format": "uuid"}`},
+		// slices.String of pop/v6 encodes like []string
+		{[]string{"definitions", "Widget", "properties", "labels"}, `{"type": "array", "items": {"type": "string"}}`},
+	})
+
+	document := generate(t, func(g *Generator) {
+		g.Types = []TypeMapping{{Go: "coke/models.Cents", Type: "string", Format: "decimal"}}
+	})
+	runDocumentTests(t, document, []documentTest{
+		{[]string{"definitions", "Widget", "properties", "price", "type"}, `"string"`},
+		{[]string{"definitions", "Widget", "properties", "price", "format"}, `"decimal"`},
+	})
+}
+
+func TestGenerateAssociations(t *testing.T) {
+	runDocumentTests(t, generate(t, nil), []documentTest{
+		{[]string{"definitions", "Widget", "properties", "owner"}, `{
+			"allOf": [{"$ref": "#/definitions/User"}],
+			"x-nullable": true, "x-association": "belongs_to", "x-foreign-key": "owner_id"
+		}`},
+		{[]string{"definitions", "Widget", "properties", "tags"}, `{
+			"type": "array", "items": {"$ref": "#/definitions/Tag"},
+			"x-association": "many_to_many", "x-foreign-key": "widget_id", "x-join-table": "widget_tags"
+		}`},
+		{[]string{"definitions", "User", "properties", "widgets"}, `{
+			"allOf": [{"$ref": "#/definitions/Widgets"}],
+			"x-association": "has_many", "x-foreign-key": "owner_id"
+		}`},
+		// associations aren't required
+		{[]string{"definitions", "User", "required"}, `["id", "name"]`},
+	})
+}
+
+func TestGenerateValidations(t *testing.T) {
+	runDocumentTests(t, generate(t, nil), []documentTest{
+		{[]string{"definitions", "Widget", "properties", "title", "minLength"}, `3`},
+		{[]string{"definitions", "Widget", "properties", "title", "maxLength"}, `64`},
+		{[]string{"definitions", "Widget", "properties", "price"}, `{
+			"type": "integer", "format": "int64", "minimum": 0, "exclusiveMinimum": true
+		}`},
+	})
+}
+
+func TestGenerateEnums(t *testing.T) {
+	runDocumentTests(t, generate(t, nil), []documentTest{
+		{[]string{"definitions", "Widget", "properties", "status"}, `{"type": "string", "enum": ["active", "archived"]}`},
+		{[]string{"definitions", "Widget", "properties", "priority"}, `{"type": "integer", "format": "int64", "enum": [0, 1]}`},
+	})
+
+	document := generate(t, func(g *Generator) {
+		g.EnumVarNames = true
+	})
+	runDocumentTests(t, document, []documentTest{
+		{[]string{"definitions", "Widget", "properties", "priority", "x-enum-varnames"}, `["PriorityLow", "PriorityHigh"]`},
+	})
+}
+
+func TestGenerateSliceDefinitions(t *testing.T) {
+	runDocumentTests(t, generate(t, nil), []documentTest{
+		{[]string{"definitions", "Widgets"}, `{
+			"type": "array",
+			"description": "Widgets is not required by pop and may be deleted",
+			"items": {"$ref": "#/definitions/Widget"}
+		}`},
+		{[]string{"definitions", "Tags", "items"}, `{"$ref": "#/definitions/Tag"}`},
+	})
+}
+
+func TestGenerateDescriptions(t *testing.T) {
+	runDocumentTests(t, generate(t, nil), []documentTest{
+		{[]string{"paths", "/widgets", "get", "summary"}, `"List gets all Widgets."`},
+		{[]string{"paths", "/widgets", "get", "description"}, `"List gets all Widgets. This function is mapped to the path\nGET /widgets"`},
+		{[]string{"definitions", "Widget", "description"}, `"Widget is a thing we sell."`},
+		{[]string{"definitions", "Widget", "properties", "title", "description"}, `"Title is shown in listings."`},
+	})
+}
+
+func TestGenerateOverrides(t *testing.T) {
+	runDocumentTests(t, generate(t, nil), []documentTest{
+		// swagger:"readOnly"
+		{[]string{"definitions", "Widget", "properties", "count"}, `{"type": "integer", "format": "int64", "readOnly": true}`},
+	})
+}
+
+func TestGenerateReferencedDefinitions(t *testing.T) {
+	runDocumentTests(t, generate(t, nil), []documentTest{
+		{[]string{"definitions", "APIError"}, `{
+			"type": "object",
+			"description": "APIError is the body of failed requests.",
+			"required": ["code", "message"],
+			"properties": {
+				"code": {"type": "integer", "format": "int64"},
+				"message": {"type": "string"}
+			}
+		}`},
+		{[]string{"paths", "/v1/gadget", "get", "responses", "200", "schema"}, `{"$ref": "#/definitions/v1.Gadget"}`},
+		{[]string{"paths", "/v2/gadget", "get", "responses", "200", "schema"}, `{"$ref": "#/definitions/v2.Gadget"}`},
+		{[]string{"definitions", "v2.Gadget", "properties"}, `{"title": {"type": "string"}}`},
+		{[]string{"paths", "/account", "get", "responses", "200", "schema"}, `{"$ref": "#/definitions/models.api.Account"}`},
+		{[]string{"paths", "/dto/account", "get", "responses", "200", "schema"}, `{"$ref": "#/definitions/dto.api.Account"}`},
+		{[]string{"definitions", "dto.api.Account", "properties"}, `{"login": {"type": "string"}}`},
+	})
+}
+
+func TestDefinitionKeys(t *testing.T) {
+	definitions := []parser.Definition{
+		{Name: "Account", PkgPath: "coke/models/api"},
+		{Name: "Account", PkgPath: "coke/internal/dto/api"},
+		{Name: "Widget", PkgPath: "coke/models"},
+		{Name: "Gadget", PkgPath: "example.com"},
+		{Name: "Gadget", PkgPath: "example/com"},
+		{Name: "Gadget", PkgPath: "com"},
+	}
+	g := NewGenerator("swagger.json")
+	g.definitionKeys(definitions)
+
+	want := map[string]string{
+		"coke/models/api.Account":       "models.api.Account",
+		"coke/internal/dto/api.Account": "dto.api.Account",
+		"coke/models.Widget":            "Widget",
+		"example.com.Gadget":            "example.com.Gadget",
+		"example/com.Gadget":            "example.com.Gadget",
+		"com.Gadget":                    "com.Gadget",
+	}
+	if !reflect.DeepEqual(g.definitions, want) {
+		t.Errorf("definitionKeys() = %v, want %v", g.definitions, want)
+	}
+	if len(g.Diagnostics) != 1 || g.Diagnostics[0].Severity != parser.Error {
+		t.Errorf("definitionKeys() diagnostics = %v, want the clashing Gadget keys", g.Diagnostics)
+	}
+}
@@ -0,0 +1,120 @@
+package generator
+
+import (
+	"go/types"
+
+	"github.com/fsuhrau/buffalo-swagger/parser"
+)
+
+// TypeMapping maps a fully qualified Go type to the schema it encodes as.
+// Either Type and Format are set, or As names another Go type the type
+// encodes like, e.g. slices.String encodes like []string.
+type TypeMapping struct {
+	Go       string
+	Type     string
+	Format   string
+	As       string
+	Nullable bool
+}
+
+// builtinTypes are the mappings of the predeclared types and of the types
+// commonly used in buffalo models. A mapping without Type and As accepts
+// any value.
+var builtinTypes = []TypeMapping{
+	{Go: "bool", Type: "boolean"},
+	{Go: "string", Type: "string"},
+	{Go: "int", Type: "integer", Format: "int64"},
+	{Go: "int8", Type: "integer", Format: "int32"},
+	{Go: "int16", Type: "integer", Format: "int32"},
+	{Go: "int32", Type: "integer", Format: "int32"},
+	{Go: "int64", Type: "integer", Format: "int64"},
+	{Go: "uint", Type: "integer", Format: "int64"},
+	{Go: "uint8", Type: "integer", Format: "int32"},
+	{Go: "uint16", Type: "integer", Format: "int32"},
+	{Go: "uint32", Type: "integer", Format: "int64"},
+	{Go: "uint64", Type: "integer", Format: "int64"},
+	{Go: "uintptr", Type: "integer", Format: "int64"},
+	{Go: "byte", Type: "integer", Format: "int32"},
+	{Go: "rune", Type: "integer", Format: "int32"},
+	{Go: "float32", Type: "number", Format: "float"},
+	{Go: "float64", Type: "number", Format: "double"},
+	// encoding/json encodes byte slices as base64 strings
+	{Go: "[]byte", Type: "string", Format: "byte"},
+	{Go: "[]uint8", Type: "string", Format: "byte"},
+	{Go: "interface{}"},
+	{Go: "any"},
+
+	{Go: "time.Time", Type: "string", Format: "date-time"},
+	{Go: "time.Duration", Type: "integer", Format: "int64"},
+	{Go: "encoding/json.RawMessage"},
+	{Go: "encoding/json/jsontext.Value"},
+	{Go: "github.com/gofrs/uuid.UUID", Type: "string", Format: "uuid"},
+	{Go: "github.com/google/uuid.UUID", Type: "string", Format: "uuid"},
+	{Go: "github.com/satori/go.uuid.UUID", Type: "string", Format: "uuid"},
+	{Go: "github.com/shopspring/decimal.Decimal", Type: "string", Format: "decimal"},
+	{Go: "github.com/shopspring/decimal.NullDecimal", As: "github.com/shopspring/decimal.Decimal", Nullable: true},
+
+	{Go: "github.com/gobuffalo/pop/slices.String", As: "[]string"},
+	{Go: "github.com/gobuffalo/pop/slices.Int", As: "[]int"},
+	{Go: "github.com/gobuffalo/pop/slices.Float", As: "[]float64"},
+	{Go: "github.com/gobuffalo/pop/slices.UUID", As: "[]github.com/gofrs/uuid.UUID"},
+	{Go: "github.com/gobuffalo/pop/slices.Map", As: "map[string]interface{}"},
+
+	{Go: "github.com/gobuffalo/nulls.Bool", As: "bool", Nullable: true},
+	{Go: "github.com/gobuffalo/nulls.Byte", As: "byte", Nullable: true},
+	{Go: "github.com/gobuffalo/nulls.ByteSlice", As: "[]byte", Nullable: true},
+	{Go: "github.com/gobuffalo/nulls.Float32", As: "float32", Nullable: true},
+	{Go: "github.com/gobuffalo/nulls.Float64", As: "float64", Nullable: true},
+	{Go: "github.com/gobuffalo/nulls.Int", As: "int", Nullable: true},
+	{Go: "github.com/gobuffalo/nulls.Int32", As: "int32", Nullable: true},
+	{Go: "github.com/gobuffalo/nulls.Int64", As: "int64", Nullable: true},
+	{Go: "github.com/gobuffalo/nulls.String", As: "string", Nullable: true},
+	{Go: "github.com/gobuffalo/nulls.Time", As: "time.Time", Nullable: true},
+	{Go: "github.com/gobuffalo/nulls.UInt32", As: "uint32", Nullable: true},
+	{Go: "github.com/gobuffalo/nulls.UUID", As: "github.com/gofrs/uuid.UUID", Nullable: true},
+	{Go: "database/sql.NullBool", As: "bool", Nullable: true},
+	{Go: "database/sql.NullByte", As: "byte", Nullable: true},
+	{Go: "database/sql.NullFloat64", As: "float64", Nullable: true},
+	{Go: "database/sql.NullInt16", As: "int16", Nullable: true},
+	{Go: "database/sql.NullInt32", As: "int32", Nullable: true},
+	{Go: "database/sql.NullInt64", As: "int64", Nullable: true},
+	{Go: "database/sql.NullString", As: "string", Nullable: true},
+	{Go: "database/sql.NullTime", As: "time.Time", Nullable: true},
+}
+
+// typeRegistry looks up the mappings of Go types.
+type typeRegistry map[string]TypeMapping
+
+// newTypeRegistry returns the builtin mappings extended by the configured
+// ones, which take precedence.
+func newTypeRegistry(mappings []TypeMapping) typeRegistry {
+	registry := typeRegistry{}
+	for _, m := range builtinTypes {
+		registry[m.Go] = m
+	}
+	for _, m := range mappings {
+		registry[parser.CanonicalType(m.Go)] = m
+	}
+	return registry
+}
+
+// lookup returns the mapping of a Go type.
+func (r typeRegistry) lookup(goType string) (TypeMapping, bool) {
+	m, ok := r[parser.CanonicalType(goType)]
+	return m, ok
+}
+
+// isBasicType reports whether goType is a predeclared bool, numeric or
+// string type, the types the ",string" json option applies to.
+func isBasicType(goType string) bool {
+	obj, ok := types.Universe.Lookup(goType).(*types.TypeName)
+	if !ok {
+		return false
+	}
+	basic, ok := obj.Type().(*types.Basic)
+	if !ok {
+		return false
+	}
+	info := basic.Info()
+	return info&(types.IsBoolean|types.IsNumeric|types.IsString) != 0 && info&types.IsComplex == 0
+}
@@ -0,0 +1,67 @@
+package parser
+
+import (
+	"fmt"
+	"go/token"
+)
+
+type Severity int
+
+const (
+	Warning Severity = iota
+	Error
+)
+
+func (s Severity) String() string {
+	if s == Error {
+		return "error"
+	}
+	return "warning"
+}
+
+// Diagnostic is a problem found in the project, e.g. a file that doesn't
+// parse or a type that can't be described.
+type Diagnostic struct {
+	Severity Severity
+	// Position is invalid for problems not tied to a source location
+	Position token.Position
+	Message  string
+}
+
+func (d Diagnostic) String() string {
+	if !d.Position.IsValid() {
+		return fmt.Sprintf("%s: %s", d.Severity, d.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", d.Position, d.Severity, d.Message)
+}
+
+type Diagnostics []Diagnostic
+
+// Count returns the number of diagnostics of a severity.
+func (d Diagnostics) Count(severity Severity) int {
+	n := 0
+	for _, diagnostic := range d {
+		if diagnostic.Severity == severity {
+			n++
+		}
+	}
+	return n
+}
+
+// Warnf records a warning at a position.
+func (d *Diagnostics) Warnf(pos token.Position, format string, args ...interface{}) {
+	*d = append(*d, Diagnostic{
+		Severity: Warning,
+		Position: pos,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// Errorf records an error at a position.
+func (d *Diagnostics) Errorf(pos token.Position, format string, args ...interface{}) {
+	*d = append(*d, Diagnostic{
+		Severity: Error,
+		Position: pos,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
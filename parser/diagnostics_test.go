@@ -0,0 +1,50 @@
+package parser
+
+import (
+	"go/token"
+	"strings"
+	"testing"
+)
+
+func TestDiagnostics(t *testing.T) {
+	var diagnostics Diagnostics
+	pos := token.Position{Filename: "models/widget.go", Line: 3, Column: 2}
+	diagnostics.Warnf(pos, "skipping %s", "Widget")
+	diagnostics.Errorf(token.Position{}, "can't load %s", "./models")
+
+	tests := []struct {
+		diagnostic Diagnostic
+		want       string
+	}{
+		{diagnostics[0], "models/widget.go:3:2: warning: skipping Widget"},
+		{diagnostics[1], "error: can't load ./models"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := tt.diagnostic.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+	if got := diagnostics.Count(Warning); got != 1 {
+		t.Errorf("Count(Warning) = %d, want 1", got)
+	}
+	if got := diagnostics.Count(Error); got != 1 {
+		t.Errorf("Count(Error) = %d, want 1", got)
+	}
+}
+
+func TestParseMissingApp(t *testing.T) {
+	p := NewParser(t.TempDir())
+	diagnostics, err := p.ParseProject()
+	if err != nil {
+		t.Fatalf("ParseProject() error = %v", err)
+	}
+	found := false
+	for _, d := range diagnostics {
+		found = found || (d.Severity == Error && strings.Contains(d.Message, "no func App"))
+	}
+	if !found {
+		t.Errorf("diagnostics = %v, want an error for the missing App", diagnostics)
+	}
+}
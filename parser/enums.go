@@ -0,0 +1,165 @@
+package parser
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+)
+
+// Enum is a named basic type together with the constants declared of it.
+type Enum struct {
+	// Type is the fully qualified name of the type
+	Type string
+	// Underlying is the basic type the values are encoded as
+	Underlying string
+	Values     []EnumValue
+}
+
+// EnumValue is a constant of an enum type.
+type EnumValue struct {
+	Name string
+	// Value is a string, int64, float64 or bool
+	Value interface{}
+}
+
+// parseEnums collects the constants of the named basic types of a package.
+// String types are enums whenever constants of them exist, other types only
+// when they have a String method, like the iota enums stringer generates.
+func (p *Parser) parseEnums(pkg *sourcePackage) {
+	enums := map[string]*Enum{}
+	stringers := map[string]bool{}
+	for _, f := range pkg.Files {
+		scope := newFileScope(pkg.Path, f)
+		for _, decl := range f.Decls {
+			switch decl := decl.(type) {
+			case *ast.FuncDecl:
+				if decl.Recv != nil && decl.Name.Name == "String" && decl.Type.Params.NumFields() == 0 {
+					if ident, ok := unstar(decl.Recv.List[0].Type).(*ast.Ident); ok {
+						stringers[pkg.Path+"."+ident.Name] = true
+					}
+				}
+			case *ast.GenDecl:
+				if decl.Tok != token.TYPE {
+					continue
+				}
+				for _, spec := range decl.Specs {
+					tspec, ok := spec.(*ast.TypeSpec)
+					if !ok || tspec.Assign.IsValid() {
+						continue
+					}
+					underlying := p.typeOf(tspec.Type, scope)
+					if _, ok := types.Universe.Lookup(underlying).(*types.TypeName); !ok {
+						continue
+					}
+					enumType := pkg.Path + "." + tspec.Name.Name
+					enums[enumType] = &Enum{
+						Type:       enumType,
+						Underlying: underlying,
+					}
+				}
+			}
+		}
+	}
+
+	// constants may be declared before their types
+	for _, f := range pkg.Files {
+		scope := newFileScope(pkg.Path, f)
+		for _, decl := range f.Decls {
+			if decl, ok := decl.(*ast.GenDecl); ok && decl.Tok == token.CONST {
+				p.enumConstants(decl, scope, enums)
+			}
+		}
+	}
+
+	for _, enum := range enums {
+		if len(enum.Values) == 0 {
+			continue
+		}
+		if enum.Underlying != "string" && !stringers[enum.Type] {
+			continue
+		}
+		if p.Enums == nil {
+			p.Enums = map[string]Enum{}
+		}
+		p.Enums[enum.Type] = *enum
+	}
+}
+
+// enumConstants adds the constants of a const declaration to their enums.
+// Specs without type and values repeat the previous ones with the next
+// iota.
+func (p *Parser) enumConstants(decl *ast.GenDecl, scope fileScope, enums map[string]*Enum) {
+	var typeExpr ast.Expr
+	var values []ast.Expr
+	for iota, spec := range decl.Specs {
+		vspec, ok := spec.(*ast.ValueSpec)
+		if !ok {
+			continue
+		}
+		if vspec.Type != nil || len(vspec.Values) > 0 {
+			typeExpr, values = vspec.Type, vspec.Values
+		}
+
+		for i, name := range vspec.Names {
+			if name.Name == "_" {
+				continue
+			}
+			var enumType string
+			var value constant.Value
+			if obj, ok := p.constObject(name); ok {
+				enumType, value = typeString(obj.Type()), obj.Val()
+			} else if typeExpr != nil && i < len(values) {
+				enumType = qualifiedExpr(typeExpr, scope)
+				value = p.evalConstant(values[i], constant.MakeInt64(int64(iota)))
+			}
+
+			enum, ok := enums[enumType]
+			if !ok || value == nil {
+				continue
+			}
+			if v := constantInterface(value); v != nil && !enum.hasValue(v) {
+				enum.Values = append(enum.Values, EnumValue{
+					Name:  name.Name,
+					Value: v,
+				})
+			}
+		}
+	}
+}
+
+// constObject returns the constant go/types declared for an identifier.
+func (p *Parser) constObject(ident *ast.Ident) (*types.Const, bool) {
+	if p.info == nil {
+		return nil, false
+	}
+	obj, ok := p.info.Defs[ident].(*types.Const)
+	return obj, ok
+}
+
+func (e *Enum) hasValue(value interface{}) bool {
+	for _, v := range e.Values {
+		if v.Value == value {
+			return true
+		}
+	}
+	return false
+}
+
+// constantInterface converts a constant to the Go value it encodes as.
+func constantInterface(value constant.Value) interface{} {
+	switch value.Kind() {
+	case constant.String:
+		return constant.StringVal(value)
+	case constant.Bool:
+		return constant.BoolVal(value)
+	case constant.Int:
+		if i, ok := constant.Int64Val(value); ok {
+			return i
+		}
+	case constant.Float:
+		f, _ := constant.Float64Val(value)
+		return f
+	}
+	return nil
+}
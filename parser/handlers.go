@@ -0,0 +1,482 @@
+package parser
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type Response struct {
+	// Status is 0 when the status code is not a constant.
+	Status int
+	// Render is the render.Engine method used, e.g. JSON, XML or Auto.
+	Render string
+	// Type is the Go type of the rendered value.
+	Type     string
+	Position token.Position
+}
+
+// Bind is the target of a c.Bind call.
+type Bind struct {
+	Type     string
+	Position token.Position
+}
+
+// Param is a request parameter read by a handler. Whether it's a path or
+// query parameter depends on the route the handler is mounted on.
+type Param struct {
+	Name string
+	// Type is the Go type the value is converted to, string by default.
+	Type     string
+	Position token.Position
+}
+
+// ErrorCode is a status code a handler fails with, either through c.Error
+// or by returning an error, which buffalo turns into a 500.
+type ErrorCode struct {
+	Status   int
+	Position token.Position
+}
+
+type Handler struct {
+	Name string
+	// Doc is the doc comment of the handler function
+	Doc       string
+	Responses []Response
+	Bind      *Bind
+	Params    []Param
+	Errors    []ErrorCode
+}
+
+// paramConversions maps parse functions to the Go type they produce.
+var paramConversions = map[string]string{
+	"strconv.Atoi":                          "int",
+	"strconv.ParseInt":                      "int64",
+	"strconv.ParseUint":                     "uint64",
+	"strconv.ParseFloat":                    "float64",
+	"strconv.ParseBool":                     "bool",
+	"github.com/gofrs/uuid.FromString":      "github.com/gofrs/uuid.UUID",
+	"github.com/gofrs/uuid.FromStringOrNil": "github.com/gofrs/uuid.UUID",
+	"github.com/google/uuid.Parse":          "github.com/google/uuid.UUID",
+	"github.com/google/uuid.MustParse":      "github.com/google/uuid.UUID",
+}
+
+// localTypes maps the variables of a handler to their Go types.
+type localTypes struct {
+	p     *Parser
+	scope fileScope
+	vars  map[string]string
+}
+
+func (p *Parser) parseHandlers() {
+	p.Handlers = map[string]*Handler{}
+	for _, route := range p.Routes {
+		if _, ok := p.Handlers[route.Handler]; ok {
+			continue
+		}
+		funcDecl, ok := p.funcs[route.Handler]
+		if !ok {
+			p.Diagnostics.Warnf(route.Position, "handler %s isn't a function of the actions package, its responses are unknown", route.Handler)
+			continue
+		}
+		p.Handlers[route.Handler] = p.parseHandler(route.Handler, funcDecl)
+	}
+
+	for _, name := range p.ErrorHandlers {
+		if _, ok := p.Handlers[name]; ok {
+			continue
+		}
+		if funcDecl, ok := p.funcs[name]; ok {
+			p.Handlers[name] = p.parseHandler(name, funcDecl)
+		}
+	}
+}
+
+func (p *Parser) parseHandler(name string, funcDecl *ast.FuncDecl) *Handler {
+	handler := &Handler{
+		Name: name,
+		Doc:  docText(funcDecl.Doc),
+	}
+	scope := p.scopes[name]
+	ctx := p.contextName(funcDecl, scope)
+	if ctx == "" {
+		return handler
+	}
+	vars := p.handlerLocals(funcDecl, scope)
+
+	ast.Inspect(funcDecl.Body, func(node ast.Node) bool {
+		call, ok := node.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		switch {
+		case isContextCall(call, ctx, "Render") && len(call.Args) == 2:
+			handler.Responses = append(handler.Responses, Response{
+				Status:   p.statusCode(call.Args[0]),
+				Render:   renderName(call.Args[1]),
+				Type:     renderType(call.Args[1], vars),
+				Position: p.fset.Position(call.Pos()),
+			})
+		case isContextCall(call, ctx, "Error") && len(call.Args) == 2:
+			handler.addError(p.statusCode(call.Args[0]), p.fset.Position(call.Pos()))
+		case isContextCall(call, ctx, "Bind") && len(call.Args) == 1 && handler.Bind == nil:
+			if goType := vars.typeOf(call.Args[0]); goType != "" {
+				handler.Bind = &Bind{
+					Type:     goType,
+					Position: p.fset.Position(call.Pos()),
+				}
+			}
+		}
+		return true
+	})
+
+	// errors returned by the handler itself end up as a 500
+	ast.Inspect(funcDecl.Body, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.ReturnStmt:
+			if len(node.Results) == 1 && isErrorExpr(node.Results[0]) {
+				handler.addError(http.StatusInternalServerError, p.fset.Position(node.Pos()))
+			}
+		}
+		return true
+	})
+
+	p.parseParams(handler, funcDecl, ctx, scope)
+	return handler
+}
+
+func (h *Handler) addError(status int, pos token.Position) {
+	if status == 0 {
+		return
+	}
+	for _, e := range h.Errors {
+		if e.Status == status {
+			return
+		}
+	}
+	h.Errors = append(h.Errors, ErrorCode{
+		Status:   status,
+		Position: pos,
+	})
+}
+
+// isErrorExpr reports whether a returned expression is an error rather
+// than the result of rendering through the context.
+func isErrorExpr(expr ast.Expr) bool {
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		return expr.Name != "nil"
+	case *ast.CallExpr:
+		fun := types.ExprString(expr.Fun)
+		return strings.HasPrefix(fun, "errors.") || fun == "fmt.Errorf"
+	}
+	return false
+}
+
+// parseParams collects the parameters read with c.Param, c.Params().Get
+// and c.Request().URL.Query().Get and types them by the conversion their
+// value is passed to.
+func (p *Parser) parseParams(handler *Handler, funcDecl *ast.FuncDecl, ctx string, scope fileScope) {
+	index := map[string]int{}
+	calls := map[*ast.CallExpr]int{}
+	vars := map[string]int{}
+
+	addParam := func(call *ast.CallExpr) (int, bool) {
+		if i, ok := calls[call]; ok {
+			return i, true
+		}
+		name, ok := paramName(call, ctx)
+		if !ok {
+			return 0, false
+		}
+		i, ok := index[name]
+		if !ok {
+			i = len(handler.Params)
+			index[name] = i
+			handler.Params = append(handler.Params, Param{
+				Name:     name,
+				Type:     "string",
+				Position: p.fset.Position(call.Pos()),
+			})
+		}
+		calls[call] = i
+		return i, true
+	}
+
+	ast.Inspect(funcDecl.Body, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.CallExpr:
+			addParam(node)
+		case *ast.AssignStmt:
+			// id := c.Param("id")
+			if len(node.Lhs) != len(node.Rhs) {
+				return true
+			}
+			for n, rhs := range node.Rhs {
+				call, ok := rhs.(*ast.CallExpr)
+				ident, isIdent := node.Lhs[n].(*ast.Ident)
+				if !ok || !isIdent {
+					continue
+				}
+				if i, ok := addParam(call); ok {
+					vars[ident.Name] = i
+				}
+			}
+		}
+		return true
+	})
+
+	ast.Inspect(funcDecl.Body, func(node ast.Node) bool {
+		call, ok := node.(*ast.CallExpr)
+		if !ok || len(call.Args) == 0 {
+			return true
+		}
+		goType, ok := paramConversions[CanonicalType(qualifiedExpr(call.Fun, scope))]
+		if !ok {
+			return true
+		}
+		if resolved, ok := p.infoType(call); ok {
+			goType = resolved
+		}
+		arg := call.Args[0]
+		if i, ok := calls[unparen(arg)]; ok {
+			handler.Params[i].Type = goType
+		} else if ident, ok := arg.(*ast.Ident); ok {
+			if i, ok := vars[ident.Name]; ok {
+				handler.Params[i].Type = goType
+			}
+		}
+		return true
+	})
+}
+
+// paramName returns the name of a parameter read by call.
+func paramName(call *ast.CallExpr, ctx string) (string, bool) {
+	if len(call.Args) != 1 {
+		return "", false
+	}
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return "", false
+	}
+
+	switch sel.Sel.Name {
+	case "Param":
+		// c.Param("name")
+		if !isContextCall(call, ctx, "Param") {
+			return "", false
+		}
+	case "Get":
+		recv, ok := sel.X.(*ast.CallExpr)
+		if !ok {
+			return "", false
+		}
+		// c.Params().Get("name")
+		if isContextCall(recv, ctx, "Params") {
+			break
+		}
+		// c.Request().URL.Query().Get("name")
+		query, ok := recv.Fun.(*ast.SelectorExpr)
+		if !ok || query.Sel.Name != "Query" {
+			return "", false
+		}
+		url, ok := query.X.(*ast.SelectorExpr)
+		if !ok || url.Sel.Name != "URL" {
+			return "", false
+		}
+		req, ok := url.X.(*ast.CallExpr)
+		if !ok || !isContextCall(req, ctx, "Request") {
+			return "", false
+		}
+	default:
+		return "", false
+	}
+	return stringLiteral(call.Args[0])
+}
+
+// unparen returns the call wrapped by an expression, or nil.
+func unparen(expr ast.Expr) *ast.CallExpr {
+	for {
+		switch e := expr.(type) {
+		case *ast.ParenExpr:
+			expr = e.X
+		case *ast.CallExpr:
+			return e
+		default:
+			return nil
+		}
+	}
+}
+
+// contextName returns the name of the buffalo.Context parameter.
+func (p *Parser) contextName(funcDecl *ast.FuncDecl, scope fileScope) string {
+	for _, field := range funcDecl.Type.Params.List {
+		if len(field.Names) == 0 {
+			continue
+		}
+		goType := p.typeOf(field.Type, scope)
+		if CanonicalType(goType) == "github.com/gobuffalo/buffalo.Context" || goType == "buffalo.Context" {
+			return field.Names[0].Name
+		}
+	}
+	return ""
+}
+
+// isContextCall reports whether call is ctx.<method>(...).
+func isContextCall(call *ast.CallExpr, ctx string, method string) bool {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != method {
+		return false
+	}
+	ident, ok := sel.X.(*ast.Ident)
+	return ok && ident.Name == ctx
+}
+
+// statusCode resolves integer constants, without type information integer
+// literals and net/http status constants.
+func (p *Parser) statusCode(expr ast.Expr) int {
+	if p.info != nil {
+		if tv, ok := p.info.Types[expr]; ok && tv.Value != nil && tv.Value.Kind() == constant.Int {
+			if code, ok := constant.Int64Val(tv.Value); ok {
+				return int(code)
+			}
+		}
+	}
+
+	switch expr := expr.(type) {
+	case *ast.BasicLit:
+		if expr.Kind == token.INT {
+			code, _ := strconv.Atoi(expr.Value)
+			return code
+		}
+	case *ast.SelectorExpr:
+		if pkg, ok := expr.X.(*ast.Ident); ok && pkg.Name == "http" {
+			return httpStatusCodes[expr.Sel.Name]
+		}
+	}
+	return 0
+}
+
+// renderName returns the render.Engine method of r.JSON(x) and friends.
+func renderName(expr ast.Expr) string {
+	call, ok := expr.(*ast.CallExpr)
+	if !ok {
+		return ""
+	}
+	if sel, ok := call.Fun.(*ast.SelectorExpr); ok {
+		return sel.Sel.Name
+	}
+	return ""
+}
+
+// renderType returns the Go type of the value passed to a renderer.
+func renderType(expr ast.Expr, vars *localTypes) string {
+	call, ok := expr.(*ast.CallExpr)
+	if !ok {
+		return ""
+	}
+	switch renderName(expr) {
+	case "JSON", "XML":
+		if len(call.Args) == 1 {
+			return vars.typeOf(call.Args[0])
+		}
+	case "Auto":
+		if len(call.Args) == 2 {
+			return vars.typeOf(call.Args[1])
+		}
+	case "String", "HTML":
+		return "string"
+	}
+	return ""
+}
+
+// handlerLocals collects the types of parameters and local variables
+// that can be read from their declaration.
+func (p *Parser) handlerLocals(funcDecl *ast.FuncDecl, scope fileScope) *localTypes {
+	vars := &localTypes{
+		p:     p,
+		scope: scope,
+		vars:  map[string]string{},
+	}
+	for _, field := range funcDecl.Type.Params.List {
+		for _, name := range field.Names {
+			vars.vars[name.Name] = p.typeOf(field.Type, scope)
+		}
+	}
+
+	ast.Inspect(funcDecl.Body, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.AssignStmt:
+			if len(node.Lhs) != len(node.Rhs) {
+				return true
+			}
+			for i, lhs := range node.Lhs {
+				if ident, ok := lhs.(*ast.Ident); ok {
+					if goType := vars.typeOf(node.Rhs[i]); goType != "" {
+						vars.vars[ident.Name] = goType
+					}
+				}
+			}
+		case *ast.ValueSpec:
+			for i, name := range node.Names {
+				if node.Type != nil {
+					vars.vars[name.Name] = p.typeOf(node.Type, scope)
+				} else if i < len(node.Values) {
+					vars.vars[name.Name] = vars.typeOf(node.Values[i])
+				}
+			}
+		}
+		return true
+	})
+	return vars
+}
+
+// typeOf returns the Go type of an expression, pointers are dereferenced
+// as they render the same.
+func (vars *localTypes) typeOf(expr ast.Expr) string {
+	if goType, ok := vars.p.infoType(expr); ok {
+		return strings.TrimLeft(goType, "*")
+	}
+
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		if expr.Name == "true" || expr.Name == "false" {
+			return "bool"
+		}
+		return strings.TrimLeft(vars.vars[expr.Name], "*")
+	case *ast.BasicLit:
+		switch expr.Kind {
+		case token.STRING:
+			return "string"
+		case token.INT:
+			return "int"
+		case token.FLOAT:
+			return "float64"
+		}
+	case *ast.CompositeLit:
+		if expr.Type != nil {
+			return vars.p.typeOf(expr.Type, vars.scope)
+		}
+	case *ast.UnaryExpr:
+		if expr.Op == token.AND {
+			return vars.typeOf(expr.X)
+		}
+	case *ast.StarExpr:
+		return vars.typeOf(expr.X)
+	case *ast.ParenExpr:
+		return vars.typeOf(expr.X)
+	case *ast.CallExpr:
+		if ident, ok := expr.Fun.(*ast.Ident); ok && len(expr.Args) > 0 {
+			if ident.Name == "new" || ident.Name == "make" {
+				return vars.p.typeOf(expr.Args[0], vars.scope)
+			}
+		}
+	}
+	return ""
+}
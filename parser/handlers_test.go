@@ -0,0 +1,149 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func findHandler(t *testing.T, p *Parser, name string) *Handler {
+	t.Helper()
+	handler, ok := p.Handlers[name]
+	if !ok {
+		t.Fatalf("handler %s not found", name)
+	}
+	return handler
+}
+
+func TestParseResponses(t *testing.T) {
+	p := parseFixture(t)
+
+	type response struct {
+		Status int
+		Render string
+		Type   string
+	}
+	tests := []struct {
+		handler   string
+		responses []response
+	}{
+		{"HomeHandler", []response{{200, "String", "string"}}},
+		{"StatusHandler", []response{{200, "JSON", "map[string]string"}}},
+		{"WidgetsResource.List", []response{{200, "JSON", "coke/models.Widgets"}}},
+		{"WidgetsResource.Show", []response{{200, "Auto", "coke/models.Widget"}}},
+		{"WidgetsResource.Create", []response{{422, "JSON", "coke/actions.APIError"}, {201, "JSON", "coke/models.Widget"}}},
+		// var widget models.Widget
+		{"WidgetsResource.Update", []response{{200, "JSON", "coke/models.Widget"}}},
+		{"WidgetsResource.Destroy", []response{{204, "", ""}}},
+		{"TagsResource.List", []response{{200, "JSON", "coke/models.Tags"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.handler, func(t *testing.T) {
+			handler := findHandler(t, p, tt.handler)
+			var responses []response
+			for _, res := range handler.Responses {
+				responses = append(responses, response{res.Status, res.Render, res.Type})
+			}
+			if !reflect.DeepEqual(responses, tt.responses) {
+				t.Errorf("Responses = %+v, want %+v", responses, tt.responses)
+			}
+		})
+	}
+}
+
+func TestParseBind(t *testing.T) {
+	p := parseFixture(t)
+
+	tests := []struct {
+		handler string
+		bind    string
+	}{
+		{"WidgetsResource.Create", "coke/models.Widget"},
+		{"WidgetsResource.Update", "coke/models.Widget"},
+		{"WidgetsResource.List", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.handler, func(t *testing.T) {
+			handler := findHandler(t, p, tt.handler)
+			bind := ""
+			if handler.Bind != nil {
+				bind = handler.Bind.Type
+			}
+			if bind != tt.bind {
+				t.Errorf("Bind = %q, want %q", bind, tt.bind)
+			}
+		})
+	}
+}
+
+func TestParseParams(t *testing.T) {
+	p := parseFixture(t)
+
+	type param struct {
+		Name string
+		Type string
+	}
+	tests := []struct {
+		handler string
+		params  []param
+	}{
+		// uuid.FromString(c.Param("widget_id"))
+		{"WidgetsResource.Show", []param{{"widget_id", "github.com/gofrs/uuid.UUID"}}},
+		{"WidgetsResource.Destroy", []param{{"widget_id", "string"}}},
+		// strconv.Atoi(c.Param("days"))
+		{"AdminStats", []param{{"days", "int"}}},
+		{"HomeHandler", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.handler, func(t *testing.T) {
+			handler := findHandler(t, p, tt.handler)
+			var params []param
+			for _, prm := range handler.Params {
+				params = append(params, param{prm.Name, prm.Type})
+			}
+			if !reflect.DeepEqual(params, tt.params) {
+				t.Errorf("Params = %+v, want %+v", params, tt.params)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	p := parseFixture(t)
+
+	tests := []struct {
+		handler string
+		errors  []int
+	}{
+		{"WidgetsResource.Show", []int{404}},
+		{"WidgetsResource.Destroy", []int{404}},
+		{"AdminStats", []int{400}},
+		// returning the error of c.Bind fails with a 500
+		{"WidgetsResource.Create", []int{500}},
+		{"HomeHandler", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.handler, func(t *testing.T) {
+			handler := findHandler(t, p, tt.handler)
+			var errors []int
+			for _, e := range handler.Errors {
+				errors = append(errors, e.Status)
+			}
+			if !reflect.DeepEqual(errors, tt.errors) {
+				t.Errorf("Errors = %v, want %v", errors, tt.errors)
+			}
+		})
+	}
+}
+
+func TestParseErrorHandlers(t *testing.T) {
+	p := parseFixture(t)
+
+	if got := p.ErrorHandlers[500]; got != "customError" {
+		t.Errorf("ErrorHandlers[500] = %q, want customError", got)
+	}
+	// error handlers render with the status they are called with
+	handler := findHandler(t, p, "customError")
+	if len(handler.Responses) != 1 || handler.Responses[0].Type != "coke/actions.APIError" {
+		t.Errorf("Responses = %+v, want one of coke/actions.APIError", handler.Responses)
+	}
+}
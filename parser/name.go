@@ -1,6 +1,7 @@
 package parser
 
 import (
+	"go/ast"
 	"strings"
 
 	"github.com/markbates/inflect"
@@ -12,3 +13,21 @@ func (n Name) VarNameUnderscore() string {
 	name := strings.Replace(string(n), "ID", "Id", -1)
 	return inflect.Underscore(name)
 }
+
+func (n Name) IsExported() bool {
+	return ast.IsExported(string(n))
+}
+
+// UnqualifiedName strips the package from models.Widget.
+func UnqualifiedName(goType string) string {
+	return goType[strings.LastIndex(goType, ".")+1:]
+}
+
+// PackagePath returns the package of models.Widget, which is empty for
+// types like Widget.
+func PackagePath(goType string) string {
+	if i := strings.LastIndex(goType, "."); i >= 0 {
+		return goType[:i]
+	}
+	return ""
+}
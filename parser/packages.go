@@ -0,0 +1,431 @@
+package parser
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"go/types"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/tools/go/packages"
+)
+
+// majorVersionRegexp matches the /vN major version elements of an import path.
+var majorVersionRegexp = regexp.MustCompile(`/v[0-9]+(/|\.|$)`)
+
+// sourcePackage is a parsed package of the project.
+type sourcePackage struct {
+	Path string
+	// Dir is the directory of the package relative to the project
+	Dir   string
+	Files []*ast.File
+}
+
+// fileScope resolves the identifiers of type expressions in a file when
+// the project can't be type checked.
+type fileScope struct {
+	pkgPath string
+	imports map[string]string
+}
+
+// CanonicalType drops the major version elements from the import paths of
+// a qualified type, github.com/gofrs/uuid/v5.UUID becomes
+// github.com/gofrs/uuid.UUID. It matches the types of other modules against
+// known types, the project's own packages like api/v1 and api/v2 are kept
+// apart by their exact import paths.
+func CanonicalType(goType string) string {
+	return majorVersionRegexp.ReplaceAllString(goType, "$1")
+}
+
+// SamePackage reports whether an import path refers to the package of a
+// definition. The packages of projects without go.mod are named after
+// their directories, which the import paths of them end with.
+func SamePackage(importPath string, pkgPath string) bool {
+	return importPath == pkgPath || (pkgPath != "" && strings.HasSuffix(importPath, "/"+pkgPath))
+}
+
+// loadPackage loads a package of the project by its directory.
+func (p *Parser) loadPackage(dir string) (*sourcePackage, error) {
+	pkgs, err := p.loadPackages("./" + filepath.ToSlash(dir))
+	if err != nil {
+		return nil, err
+	}
+	if len(pkgs) == 0 {
+		return &sourcePackage{Dir: dir}, nil
+	}
+	return pkgs[0], nil
+}
+
+// loadPackages loads the packages matching go package patterns like
+// ./models/... with type information. When the go tool can't load the
+// project, e.g. because dependencies are missing, the files of the
+// matching directories of the project are only parsed and types are read
+// from the syntax.
+func (p *Parser) loadPackages(patterns ...string) ([]*sourcePackage, error) {
+	root, err := filepath.Abs(p.Project)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &packages.Config{
+		Mode: packages.NeedName | packages.NeedFiles | packages.NeedSyntax |
+			packages.NeedTypes | packages.NeedTypesInfo | packages.NeedImports,
+		Dir:  p.Project,
+		Fset: p.fset,
+	}
+	loaded, err := packages.Load(cfg, patterns...)
+	var pkgs []*sourcePackage
+	var diagnostics Diagnostics
+	if err != nil {
+		p.Diagnostics.Warnf(token.Position{}, "can't load %s with the go tool, types are read from the syntax: %s", strings.Join(patterns, " "), err)
+	} else {
+		for _, pkg := range loaded {
+			parseErrors := false
+			for _, e := range pkg.Errors {
+				parseErrors = parseErrors || e.Kind == packages.ParseError
+			}
+			for _, e := range pkg.Errors {
+				switch {
+				case e.Kind == packages.ParseError:
+					diagnostics.Errorf(errorPosition(e.Pos), "%s", e.Msg)
+				case e.Kind == packages.ListError && parseErrors:
+					// the go tool repeats the syntax errors
+				default:
+					diagnostics.Warnf(errorPosition(e.Pos), "%s", e.Msg)
+				}
+			}
+			if len(pkg.Syntax) == 0 || len(pkg.GoFiles) == 0 || pkg.TypesInfo == nil {
+				continue
+			}
+			p.mergeInfo(pkg.TypesInfo)
+			dir, _ := filepath.Rel(root, filepath.Dir(pkg.GoFiles[0]))
+			pkgs = append(pkgs, &sourcePackage{
+				Path:  pkg.PkgPath,
+				Dir:   filepath.ToSlash(dir),
+				Files: pkg.Syntax,
+			})
+		}
+	}
+	if len(pkgs) > 0 {
+		p.Diagnostics = append(p.Diagnostics, diagnostics...)
+		return pkgs, nil
+	}
+	// parsing the directories reports the syntax errors again
+	return p.parseDirs(root, patterns)
+}
+
+// errorPosition parses the file:line:column positions of the go tool's
+// errors, line and column are optional.
+func errorPosition(pos string) token.Position {
+	var position token.Position
+	if pos == "" || pos == "-" {
+		return position
+	}
+	for _, n := range []*int{&position.Column, &position.Line} {
+		i := strings.LastIndex(pos, ":")
+		if i < 0 {
+			break
+		}
+		value, err := strconv.Atoi(pos[i+1:])
+		if err != nil {
+			break
+		}
+		*n = value
+		pos = pos[:i]
+	}
+	// file:line was read as line:column
+	if position.Line == 0 {
+		position.Line, position.Column = position.Column, 0
+	}
+	position.Filename = pos
+	return position
+}
+
+// parseDirs parses the directories of the project matching the patterns.
+// Import paths outside of the project's module can't be found without the
+// go tool and are skipped.
+func (p *Parser) parseDirs(root string, patterns []string) ([]*sourcePackage, error) {
+	modulePath := p.modulePath()
+	seen := map[string]bool{}
+	var pkgs []*sourcePackage
+	for _, pattern := range patterns {
+		dir, recursive := strings.CutSuffix(pattern, "/...")
+		dir, ok := projectDir(dir, modulePath)
+		if !ok {
+			p.Diagnostics.Warnf(token.Position{}, "can't find package %s without the go tool", pattern)
+			continue
+		}
+
+		dirs := []string{dir}
+		if recursive {
+			dirs = nil
+			filepath.WalkDir(filepath.Join(root, dir), func(file string, entry os.DirEntry, err error) error {
+				if err != nil || !entry.IsDir() {
+					return nil
+				}
+				// the go tool ignores these directories as well
+				name := entry.Name()
+				if file != filepath.Join(root, dir) && (name == "testdata" || name == "vendor" ||
+					strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+					return filepath.SkipDir
+				}
+				rel, _ := filepath.Rel(root, file)
+				dirs = append(dirs, filepath.ToSlash(rel))
+				return nil
+			})
+		}
+
+		for _, dir := range dirs {
+			if seen[dir] {
+				continue
+			}
+			seen[dir] = true
+			pkg, err := p.parseDir(dir)
+			if err != nil {
+				return nil, err
+			}
+			if len(pkg.Files) > 0 {
+				pkgs = append(pkgs, pkg)
+			}
+		}
+	}
+	return pkgs, nil
+}
+
+// projectDir returns the directory of the project a package pattern
+// without /... refers to.
+func projectDir(pattern string, modulePath string) (string, bool) {
+	switch {
+	case pattern == "." || strings.HasPrefix(pattern, "./"):
+		return path.Clean(pattern), true
+	case modulePath != "" && pattern == modulePath:
+		return ".", true
+	case modulePath != "" && strings.HasPrefix(pattern, modulePath+"/"):
+		return strings.TrimPrefix(pattern, modulePath+"/"), true
+	}
+	return "", false
+}
+
+// matches reports whether a package matches a package pattern. Relative
+// patterns match the directory of the package, others its import path.
+func (pkg *sourcePackage) matches(pattern string) bool {
+	target := pkg.Path
+	pattern, recursive := strings.CutSuffix(pattern, "/...")
+	if pattern == "." || strings.HasPrefix(pattern, "./") {
+		pattern = path.Clean(pattern)
+		target = pkg.Dir
+		if recursive && pattern == "." {
+			return true
+		}
+	}
+	return target == pattern || (recursive && strings.HasPrefix(target, pattern+"/"))
+}
+
+// parseDir parses the non-test files of a directory.
+func (p *Parser) parseDir(dir string) (*sourcePackage, error) {
+	pkg := &sourcePackage{
+		Path: p.modulePath(),
+		Dir:  filepath.ToSlash(dir),
+	}
+	if pkg.Dir != "." {
+		pkg.Path = path.Join(pkg.Path, pkg.Dir)
+	}
+	files, _ := filepath.Glob(filepath.Join(p.Project, dir, "*.go"))
+	for _, file := range files {
+		// skip test files
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+
+		f, err := parser.ParseFile(p.fset, file, nil, parser.AllErrors|parser.ParseComments)
+		if list, ok := err.(scanner.ErrorList); ok {
+			for _, e := range list {
+				p.Diagnostics.Errorf(e.Pos, "%s", e.Msg)
+			}
+		} else if err != nil {
+			p.Diagnostics.Errorf(token.Position{Filename: file}, "%s", err)
+		}
+		if f != nil {
+			pkg.Files = append(pkg.Files, f)
+		}
+	}
+	return pkg, nil
+}
+
+// modulePath returns the module path of the project's go.mod, if any.
+func (p *Parser) modulePath() string {
+	data, err := os.ReadFile(filepath.Join(p.Project, "go.mod"))
+	if err != nil {
+		return ""
+	}
+	return modfile.ModulePath(data)
+}
+
+func (p *Parser) mergeInfo(info *types.Info) {
+	if p.info == nil {
+		p.info = &types.Info{
+			Types: map[ast.Expr]types.TypeAndValue{},
+			Defs:  map[*ast.Ident]types.Object{},
+			Uses:  map[*ast.Ident]types.Object{},
+		}
+	}
+	for expr, tv := range info.Types {
+		p.info.Types[expr] = tv
+	}
+	for ident, obj := range info.Defs {
+		p.info.Defs[ident] = obj
+	}
+	for ident, obj := range info.Uses {
+		p.info.Uses[ident] = obj
+	}
+}
+
+// infoType returns the fully qualified type go/types resolved for an
+// expression.
+func (p *Parser) infoType(expr ast.Expr) (string, bool) {
+	if p.info == nil {
+		return "", false
+	}
+	tv, ok := p.info.Types[expr]
+	if !ok || tv.Type == nil {
+		return "", false
+	}
+	goType := typeString(tv.Type)
+	if strings.Contains(goType, "invalid type") {
+		return "", false
+	}
+	p.namedUnderlying(tv.Type)
+	return goType, true
+}
+
+// namedUnderlying records the underlying types of the named types a type
+// refers to, except for structs, which become definitions.
+func (p *Parser) namedUnderlying(t types.Type) {
+	switch t := types.Unalias(t).(type) {
+	case *types.Named:
+		switch underlying := t.Underlying().(type) {
+		case *types.Struct, *types.Interface:
+		default:
+			goType := typeString(t)
+			if _, ok := p.Underlying[goType]; !ok && !strings.Contains(typeString(underlying), "invalid type") {
+				p.addUnderlying(goType, typeString(underlying))
+				p.namedUnderlying(underlying)
+			}
+		}
+	case *types.Pointer:
+		p.namedUnderlying(t.Elem())
+	case *types.Slice:
+		p.namedUnderlying(t.Elem())
+	case *types.Array:
+		p.namedUnderlying(t.Elem())
+	case *types.Map:
+		p.namedUnderlying(t.Key())
+		p.namedUnderlying(t.Elem())
+	}
+}
+
+// typeOf returns the fully qualified type of a type expression.
+func (p *Parser) typeOf(expr ast.Expr, scope fileScope) string {
+	if goType, ok := p.infoType(expr); ok {
+		return goType
+	}
+	return qualifiedExpr(expr, scope)
+}
+
+// typeString formats a type with import paths as package qualifiers and
+// aliases resolved to the types they stand for.
+func typeString(t types.Type) string {
+	switch t := types.Unalias(t).(type) {
+	case *types.Basic:
+		// aliases of basic types may keep their own name
+		return types.Typ[t.Kind()].Name()
+	case *types.Named:
+		obj := t.Obj()
+		if obj.Pkg() == nil {
+			return obj.Name()
+		}
+		return obj.Pkg().Path() + "." + obj.Name()
+	case *types.Pointer:
+		return "*" + typeString(t.Elem())
+	case *types.Slice:
+		return "[]" + typeString(t.Elem())
+	case *types.Array:
+		return "[" + strconv.FormatInt(t.Len(), 10) + "]" + typeString(t.Elem())
+	case *types.Map:
+		return "map[" + typeString(t.Key()) + "]" + typeString(t.Elem())
+	case *types.Tuple:
+		// the type of a call is the type of its first result
+		if t.Len() > 0 {
+			return typeString(t.At(0).Type())
+		}
+	}
+	return types.TypeString(t, (*types.Package).Path)
+}
+
+// newFileScope collects the imports of a file by their local name.
+func newFileScope(pkgPath string, f *ast.File) fileScope {
+	scope := fileScope{
+		pkgPath: pkgPath,
+		imports: map[string]string{},
+	}
+	for _, spec := range f.Imports {
+		importPath, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+		name := importName(importPath)
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		scope.imports[name] = importPath
+	}
+	return scope
+}
+
+// importName guesses the package name of an import path, gopkg.in/yaml.v2
+// and github.com/gobuffalo/pop/v6 are imported as yaml and pop.
+func importName(importPath string) string {
+	elements := strings.Split(CanonicalType(importPath), "/")
+	name := elements[len(elements)-1]
+	if i := strings.Index(name, ".v"); i > 0 {
+		name = name[:i]
+	}
+	return name
+}
+
+// qualifiedExpr formats a type expression with the import paths of its
+// packages.
+func qualifiedExpr(expr ast.Expr, scope fileScope) string {
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		if types.Universe.Lookup(expr.Name) != nil || scope.pkgPath == "" {
+			return expr.Name
+		}
+		return scope.pkgPath + "." + expr.Name
+	case *ast.SelectorExpr:
+		if pkg, ok := expr.X.(*ast.Ident); ok {
+			if importPath, ok := scope.imports[pkg.Name]; ok {
+				return importPath + "." + expr.Sel.Name
+			}
+		}
+	case *ast.StarExpr:
+		return "*" + qualifiedExpr(expr.X, scope)
+	case *ast.ParenExpr:
+		return qualifiedExpr(expr.X, scope)
+	case *ast.ArrayType:
+		if expr.Len == nil {
+			return "[]" + qualifiedExpr(expr.Elt, scope)
+		}
+		return "[" + types.ExprString(expr.Len) + "]" + qualifiedExpr(expr.Elt, scope)
+	case *ast.MapType:
+		return "map[" + qualifiedExpr(expr.Key, scope) + "]" + qualifiedExpr(expr.Value, scope)
+	}
+	return types.ExprString(expr)
+}
@@ -1,11 +1,11 @@
 package parser
 
 import (
-	"fmt"
 	"go/ast"
-	"go/parser"
 	"go/token"
-	"path/filepath"
+	"go/types"
+	"reflect"
+	"strconv"
 	"strings"
 
 	"github.com/markbates/inflect"
@@ -15,69 +15,161 @@ type Property struct {
 	Name Name
 	Type string
 	Tag  string
+	// Description is the doc or line comment of the field
+	Description string
+	// JSONName is the key encoding/json uses for the field
+	JSONName string
+	// JSONHidden is set for json:"-" fields, which encoding/json skips
+	// but buffalo still binds from forms
+	JSONHidden bool
+	OmitEmpty  bool
+	// AsString is set by the ",string" json option
+	AsString bool
+	// Embedded fields without a json name are promoted into the parent
+	Embedded bool
+	// Fields are the properties of an inline struct type
+	Fields []Property
+	// Column is the db column, "-" for fields pop doesn't persist
+	Column string
+	// FormName is the key of the field in form encoded bodies, "-" for
+	// fields buffalo doesn't bind from forms
+	FormName string
+	Position token.Position
+	// Association is set for the pop associations of a model
+	Association *Association
+	Constraints Constraints
+	Overrides   Overrides
+}
+
+// Association is a pop association between two models.
+type Association struct {
+	// Kind is the tag declaring it: has_many, belongs_to, has_one or
+	// many_to_many
+	Kind string
+	// ForeignKey is the field referencing the other model. For belongs_to
+	// it's a property of the model itself, for has_many and has_one one of
+	// the associated model and for many_to_many a column of the join table.
+	ForeignKey string
+	JoinTable  string
 }
 
 type Definition struct {
-	Name       inflect.Name
+	Name        inflect.Name
+	Description string
+	// PkgPath is the import path of the package declaring the type
+	PkgPath    string
 	Properties []Property
+	// Items is the element type of slice types like Widgets
+	Items    string
+	Position token.Position
 }
 
-type Route struct {
-	Name   string
-	Method string
+// GoType returns the qualified name of the type of the definition.
+func (d Definition) GoType() string {
+	return d.PkgPath + "." + string(d.Name)
 }
 
 type Parser struct {
 	Project string
-	// Routes
-	Definitions []Definition
+	// Models are the package patterns definitions are generated for,
+	// ./models by default
+	Models []string
+	// ExcludeModels are package patterns excluded from Models
+	ExcludeModels []string
+
+	Routes        []Route
+	Handlers      map[string]*Handler
+	ErrorHandlers map[int]string
+	Definitions   []Definition
+	// Enums are the enum types of the models by their qualified name
+	Enums map[string]Enum
+	// Underlying maps named types that aren't structs, like models.Cents,
+	// to the types they are declared as
+	Underlying map[string]string
+	// Diagnostics are the problems found while parsing
+	Diagnostics Diagnostics
+
+	fset *token.FileSet
+	// info holds the types of the loaded packages, nil when the project
+	// couldn't be type checked
+	info     *types.Info
+	funcs    map[string]*ast.FuncDecl
+	scopes   map[string]fileScope
+	visiting map[string]bool
+	// modelPackages are the packages definitions were parsed from
+	modelPackages []*sourcePackage
+	// attempted are the referenced types looked up outside of the models
+	attempted map[string]bool
 }
 
 func NewParser(projectPath string) *Parser {
 	return &Parser{
 		Project: projectPath,
+		fset:    token.NewFileSet(),
 	}
 }
 
 func (p *Parser) parseDefinitions() error {
-	fset := token.NewFileSet()
-	files, _ := filepath.Glob(p.Project + "/models/*.go")
-	for _, file := range files {
-		// skip test files
-		if strings.HasSuffix(file, "_test.go") {
-			continue
-		}
+	patterns := p.Models
+	if len(patterns) == 0 {
+		patterns = []string{"./models"}
+	}
+	pkgs, err := p.loadPackages(patterns...)
+	if err != nil {
+		return err
+	}
 
-		f, err := parser.ParseFile(fset, file, nil, parser.AllErrors)
-		if err != nil {
-			fmt.Println(err.Error())
+	for _, pkg := range pkgs {
+		if !p.excluded(pkg) {
+			p.parseTypes(pkg, nil)
 		}
+	}
+	return nil
+}
+
+// parseTypes adds the definitions of the struct and slice types of a
+// package, only of the given names unless names is nil.
+func (p *Parser) parseTypes(pkg *sourcePackage, names map[string]bool) {
+	found := false
+	for _, modelPkg := range p.modelPackages {
+		found = found || modelPkg.Path == pkg.Path
+	}
+	if !found {
+		p.modelPackages = append(p.modelPackages, pkg)
+	}
 
+	for _, f := range pkg.Files {
+		scope := newFileScope(pkg.Path, f)
 		for _, decl := range f.Decls {
 			if typeDecl, ok := decl.(*ast.GenDecl); ok {
 				for _, strDecl := range typeDecl.Specs {
 					if tspec, ok := strDecl.(*ast.TypeSpec); ok {
+						if names != nil && !names[tspec.Name.Name] {
+							continue
+						}
 						structName := inflect.Name(tspec.Name.Name)
 						definition := Definition{
-							Name: structName,
+							Name:        structName,
+							Description: docText(tspec.Doc),
+							PkgPath:     pkg.Path,
+							Position:    p.fset.Position(tspec.Pos()),
+						}
+						if definition.Description == "" && len(typeDecl.Specs) == 1 {
+							definition.Description = docText(typeDecl.Doc)
 						}
-						if structDecl, ok := tspec.Type.(*ast.StructType); ok {
-							fields := structDecl.Fields.List
-							for _, field := range fields {
-								var varName Name
-								for _, name := range field.Names {
-									varName = Name(name.Name)
-								}
-								// for _, tag := field.Tag {
-
-								// }
-								definition.Properties = append(definition.Properties, Property{
-									Name: varName,
-									Type: fmt.Sprintf("%s", field.Type),
-								})
+						switch t := tspec.Type.(type) {
+						case *ast.StructType:
+							definition.Properties = p.structProperties(t, scope)
+						case *ast.ArrayType:
+							if t.Len == nil {
+								definition.Items = p.typeOf(t.Elt, scope)
+							} else {
+								p.addUnderlying(definition.GoType(), p.typeOf(t, scope))
 							}
+						default:
+							p.addUnderlying(definition.GoType(), p.typeOf(t, scope))
 						}
-						if len(definition.Properties) > 0 {
+						if len(definition.Properties) > 0 || definition.Items != "" {
 							p.Definitions = append(p.Definitions, definition)
 						}
 					}
@@ -85,82 +177,360 @@ func (p *Parser) parseDefinitions() error {
 			}
 		}
 	}
-	return nil
 }
 
-func (p *Parser) parseRoutes() error {
-	fset := token.NewFileSet()
-	files, _ := filepath.Glob(p.Project + "/actions/app.go")
-	for _, file := range files {
-		// skip test files
-		if strings.HasSuffix(file, "_test.go") {
-			continue
+// addUnderlying records the type a named type is declared as.
+func (p *Parser) addUnderlying(goType string, underlying string) {
+	if p.Underlying == nil {
+		p.Underlying = map[string]string{}
+	}
+	p.Underlying[goType] = underlying
+}
+
+// excluded reports whether a package matches one of the ExcludeModels.
+func (p *Parser) excluded(pkg *sourcePackage) bool {
+	for _, pattern := range p.ExcludeModels {
+		if pkg.matches(pattern) {
+			return true
 		}
+	}
+	return false
+}
+
+// parseReferencedDefinitions adds the definitions of the types of the
+// project that routes render or bind, and of the types those refer to, when
+// they live outside of the model packages.
+func (p *Parser) parseReferencedDefinitions() error {
+	modulePath := p.modulePath()
+	if modulePath == "" {
+		// import paths can't be mapped to directories
+		return nil
+	}
+	if p.attempted == nil {
+		p.attempted = map[string]bool{}
+	}
 
-		f, err := parser.ParseFile(fset, file, nil, parser.Trace)
-		if err != nil {
-			fmt.Println(err.Error())
+	for {
+		defined := map[string]bool{}
+		for _, def := range p.Definitions {
+			defined[def.GoType()] = true
 		}
 
-		for _, decl := range f.Decls {
-			if typeDecl, ok := decl.(*ast.FuncDecl); ok {
-				if "App" == typeDecl.Name.Name {
-					fmt.Println(typeDecl.Body)
-					for _, statement := range typeDecl.Body.List {
-						fmt.Println(statement)
-					}
-					// ast.Inspect(typeDecl.Body, func(node ast.Node) bool {
-					// 	if exp, ok := node.(ast.UnaryExpr); ok {
-					// 		fmt.Println(exp)
-					// 	}
-					// 	return true
-					// })
+		missing := map[string]map[string]bool{}
+		for _, goType := range p.referencedTypes() {
+			if defined[goType] || p.attempted[goType] {
+				continue
+			}
+			p.attempted[goType] = true
+			i := strings.LastIndex(goType, ".")
+			pkgPath := goType[:i]
+			if _, ok := projectDir(pkgPath, modulePath); !ok {
+				continue
+			}
+			if missing[pkgPath] == nil {
+				missing[pkgPath] = map[string]bool{}
+			}
+			missing[pkgPath][goType[i+1:]] = true
+		}
+		if len(missing) == 0 {
+			return nil
+		}
+
+		for pkgPath, names := range missing {
+			var pkg *sourcePackage
+			for _, modelPkg := range p.modelPackages {
+				if modelPkg.Path == pkgPath {
+					pkg = modelPkg
+				}
+			}
+			if pkg == nil {
+				dir, _ := projectDir(pkgPath, modulePath)
+				var err error
+				pkg, err = p.loadPackage(dir)
+				if err != nil {
+					return err
 				}
+			}
+			if !p.excluded(pkg) {
+				p.parseTypes(pkg, names)
+			}
+		}
+	}
+}
+
+// referencedTypes returns the named types the handlers render and bind and
+// the properties of the definitions refer to.
+func (p *Parser) referencedTypes() []string {
+	var goTypes []string
+	for _, handler := range p.Handlers {
+		for _, res := range handler.Responses {
+			goTypes = append(goTypes, namedTypes(res.Type)...)
+		}
+		if handler.Bind != nil {
+			goTypes = append(goTypes, namedTypes(handler.Bind.Type)...)
+		}
+	}
 
-				// for _, strDecl := range typeDecl.Specs {
-				// 	if tspec, ok := strDecl.(*ast.TypeSpec); ok {
-				// 		structName := tspec.Name.Name
-				// 		definition := Definition{
-				// 			Name: structName,
-				// 		}
-				// 		if structDecl, ok := tspec.Type.(*ast.StructType); ok {
-				// 			fields := structDecl.Fields.List
-				// 			for _, field := range fields {
-				// 				varname := ""
-				// 				for _, name := range field.Names {
-				// 					varname = name.Name
-				// 				}
-				// 				// for _, tag := field.Tag {
-
-				// 				// }
-				// 				definition.Properties = append(definition.Properties, Property{
-				// 					Name: varname,
-				// 					Type: fmt.Sprintf("%s", field.Type),
-				// 				})
-				// 			}
-				// 		}
-				// 		if len(definition.Properties) > 0 {
-				// 			p.Definitions = append(p.Definitions, definition)
-				// 		}
-				// 	}
-				// }
+	var addProperties func(props []Property)
+	addProperties = func(props []Property) {
+		for _, prop := range props {
+			if prop.JSONHidden {
+				continue
+			}
+			goTypes = append(goTypes, namedTypes(prop.Type)...)
+			addProperties(prop.Fields)
+		}
+	}
+	for _, def := range p.Definitions {
+		goTypes = append(goTypes, namedTypes(def.Items)...)
+		addProperties(def.Properties)
+	}
+	return goTypes
+}
+
+// namedTypes returns the qualified named types of a type expression like
+// []*models.Widget or map[string]models.Widget.
+func namedTypes(goType string) []string {
+	goType = strings.TrimLeft(goType, "*")
+	if strings.HasPrefix(goType, "[") || strings.HasPrefix(goType, "map[") {
+		// the element type follows the matching bracket
+		depth := 0
+		for i, c := range goType {
+			switch c {
+			case '[':
+				depth++
+			case ']':
+				depth--
+				if depth == 0 {
+					return namedTypes(goType[i+1:])
+				}
 			}
 		}
 		return nil
 	}
+	if strings.Contains(goType, ".") {
+		return []string{goType}
+	}
 	return nil
 }
 
-func (p *Parser) ParseProject() error {
+// completeDefinitions resolves what depends on all definitions being known.
+func (p *Parser) completeDefinitions() {
+	p.flattenEmbedded()
+	p.resolveAssociations()
+	for _, pkg := range p.modelPackages {
+		p.parseValidations(pkg)
+		p.parseEnums(pkg)
+	}
+}
+
+// structProperties returns the properties of the exported fields of a
+// struct, fields of inline struct types are parsed recursively.
+func (p *Parser) structProperties(structDecl *ast.StructType, scope fileScope) []Property {
+	var properties []Property
+	for _, field := range structDecl.Fields.List {
+		var tag reflect.StructTag
+		if field.Tag != nil {
+			value, _ := strconv.Unquote(field.Tag.Value)
+			tag = reflect.StructTag(value)
+		}
+
+		var fields []Property
+		if inline, ok := field.Type.(*ast.StructType); ok {
+			fields = p.structProperties(inline, scope)
+		}
+
+		goType := p.typeOf(field.Type, scope)
+		pos := p.fset.Position(field.Pos())
+		if len(field.Names) == 0 {
+			// embedded fields are named after their type
+			name := Name(UnqualifiedName(strings.TrimPrefix(goType, "*")))
+			if prop, ok := newProperty(name, goType, tag); ok && name.IsExported() {
+				prop.Position = pos
+				jsonName, _ := tagOptions(tag.Get("json"))
+				prop.Embedded = jsonName == ""
+				properties = append(properties, prop)
+			}
+			continue
+		}
+
+		for _, name := range field.Names {
+			// encoding/json ignores unexported fields
+			if !name.IsExported() {
+				continue
+			}
+			prop, ok := newProperty(Name(name.Name), goType, tag)
+			for _, problem := range prop.Overrides.invalid {
+				p.Diagnostics.Warnf(pos, "%s", problem)
+			}
+			if ok {
+				prop.Position = pos
+				prop.Description = docText(field.Doc, field.Comment)
+				prop.Fields = fields
+				properties = append(properties, prop)
+			}
+		}
+	}
+	return properties
+}
+
+// flattenEmbedded promotes the properties of embedded structs the way
+// encoding/json does, properties of the outer struct win.
+func (p *Parser) flattenEmbedded() {
+	index := newDefinitionIndex(p.Definitions)
+	for i := range p.Definitions {
+		p.Definitions[i].Properties = promotedProperties(p.Definitions[i], index, map[string]bool{})
+	}
+}
+
+func promotedProperties(def Definition, index definitionIndex, visiting map[string]bool) []Property {
+	visiting[def.GoType()] = true
+	defer delete(visiting, def.GoType())
+
+	var properties, promoted []Property
+	for _, prop := range def.Properties {
+		if !prop.Embedded {
+			properties = append(properties, prop)
+			continue
+		}
+		embedded, ok := index.lookup(prop.Type)
+		if !ok || visiting[embedded.GoType()] {
+			continue
+		}
+		promoted = append(promoted, promotedProperties(embedded, index, visiting)...)
+	}
+
+	for _, prop := range promoted {
+		shadowed := false
+		for _, outer := range properties {
+			if !outer.JSONHidden && outer.JSONName == prop.JSONName {
+				shadowed = true
+				break
+			}
+		}
+		if !shadowed {
+			properties = append(properties, prop)
+		}
+	}
+	return properties
+}
+
+// resolveAssociations fills in the foreign keys pop derives by convention
+// and names them by their json names where the models declare them.
+func (p *Parser) resolveAssociations() {
+	index := newDefinitionIndex(p.Definitions)
+	for _, def := range p.Definitions {
+		for i := range def.Properties {
+			assoc := def.Properties[i].Association
+			if assoc == nil {
+				continue
+			}
+			ownerKey := inflect.Underscore(string(def.Name)) + "_id"
+			switch assoc.Kind {
+			case "belongs_to":
+				// fk_id names the field of the model, e.g. OwnerID for Owner
+				field := assoc.ForeignKey
+				if field == "" {
+					field = string(def.Properties[i].Name) + "ID"
+				}
+				assoc.ForeignKey = Name(field).VarNameUnderscore()
+				for _, prop := range def.Properties {
+					if string(prop.Name) == field {
+						assoc.ForeignKey = prop.JSONName
+					}
+				}
+			case "has_many", "has_one":
+				// fk_id names the column of the associated model
+				if assoc.ForeignKey == "" {
+					assoc.ForeignKey = ownerKey
+				}
+				elem := strings.TrimLeft(def.Properties[i].Type, "[]*")
+				if slice, ok := index.lookup(elem); ok && slice.Items != "" {
+					elem = slice.Items
+				}
+				associated, _ := index.lookup(elem)
+				for _, prop := range associated.Properties {
+					if prop.Column == assoc.ForeignKey {
+						assoc.ForeignKey = prop.JSONName
+					}
+				}
+			case "many_to_many":
+				if assoc.ForeignKey == "" {
+					assoc.ForeignKey = ownerKey
+				}
+			}
+		}
+	}
+}
+
+// docText returns the text of the first non-empty comment group.
+func docText(groups ...*ast.CommentGroup) string {
+	for _, group := range groups {
+		if text := strings.TrimSpace(group.Text()); text != "" {
+			return text
+		}
+	}
+	return ""
+}
+
+// definitionIndex looks up definitions by the Go types referring to them.
+type definitionIndex struct {
+	types map[string]Definition
+	names map[string][]Definition
+}
+
+func newDefinitionIndex(definitions []Definition) definitionIndex {
+	index := definitionIndex{
+		types: map[string]Definition{},
+		names: map[string][]Definition{},
+	}
+	for _, def := range definitions {
+		index.types[def.GoType()] = def
+		index.names[string(def.Name)] = append(index.names[string(def.Name)], def)
+	}
+	return index
+}
+
+func (index definitionIndex) lookup(goType string) (Definition, bool) {
+	goType = strings.TrimLeft(goType, "*")
+	if def, ok := index.types[goType]; ok {
+		return def, true
+	}
+	// without type information import paths don't always match the
+	// packages, e.g. when the project has no go.mod
+	var found []Definition
+	for _, def := range index.names[UnqualifiedName(goType)] {
+		if pkgPath := PackagePath(goType); pkgPath == "" || SamePackage(pkgPath, def.PkgPath) {
+			found = append(found, def)
+		}
+	}
+	if len(found) == 1 {
+		return found[0], true
+	}
+	return Definition{}, false
+}
+
+// ParseProject parses the models and routes of the project. The returned
+// diagnostics describe the parts that couldn't be understood, the error is
+// set when parsing failed entirely.
+func (p *Parser) ParseProject() (Diagnostics, error) {
 	err := p.parseDefinitions()
 	if err != nil {
-		return err
+		return p.Diagnostics, err
 	}
 
 	err = p.parseRoutes()
 	if err != nil {
-		return err
+		return p.Diagnostics, err
 	}
 
-	return nil
+	p.parseHandlers()
+
+	err = p.parseReferencedDefinitions()
+	if err != nil {
+		return p.Diagnostics, err
+	}
+	p.completeDefinitions()
+
+	return p.Diagnostics, nil
 }
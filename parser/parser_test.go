@@ -0,0 +1,400 @@
+package parser
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// fixture is a buffalo project using stubs of the buffalo packages.
+const fixture = "../testdata/app"
+
+var (
+	fixtureOnce        sync.Once
+	fixtureParser      *Parser
+	fixtureDiagnostics Diagnostics
+	fixtureErr         error
+)
+
+// parseFixture parses the fixture project once for all tests, which only
+// read the results.
+func parseFixture(t *testing.T) *Parser {
+	t.Helper()
+	fixtureOnce.Do(func() {
+		fixtureParser = NewParser(fixture)
+		fixtureDiagnostics, fixtureErr = fixtureParser.ParseProject()
+	})
+	if fixtureErr != nil {
+		t.Fatalf("ParseProject() error = %v", fixtureErr)
+	}
+	for _, d := range fixtureDiagnostics {
+		t.Errorf("unexpected diagnostic %s", d)
+	}
+	return fixtureParser
+}
+
+func findDefinition(t *testing.T, p *Parser, goType string) Definition {
+	t.Helper()
+	for _, def := range p.Definitions {
+		if def.GoType() == goType {
+			return def
+		}
+	}
+	t.Fatalf("definition %s not found", goType)
+	return Definition{}
+}
+
+func findProperty(t *testing.T, def Definition, jsonName string) Property {
+	t.Helper()
+	for _, prop := range def.Properties {
+		if prop.JSONName == jsonName {
+			return prop
+		}
+	}
+	t.Fatalf("property %s of %s not found", jsonName, def.Name)
+	return Property{}
+}
+
+func TestParseTags(t *testing.T) {
+	p := parseFixture(t)
+	widget := findDefinition(t, p, "coke/models.Widget")
+
+	tests := []struct {
+		jsonName   string
+		name       Name
+		formName   string
+		column     string
+		omitEmpty  bool
+		jsonHidden bool
+	}{
+		{"id", "ID", "ID", "id", false, false},
+		{"title", "Title", "widget_title", "title", false, false},
+		{"dimensions", "Dimensions", "Dimensions", "-", false, false},
+		{"tags", "Tags", "Tags", "Tags", true, false},
+		// json:"-" fields are kept for forms
+		{"Password", "Password", "password", "-", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.jsonName, func(t *testing.T) {
+			prop := findProperty(t, widget, tt.jsonName)
+			if prop.Name != tt.name {
+				t.Errorf("Name = %q, want %q", prop.Name, tt.name)
+			}
+			if prop.FormName != tt.formName {
+				t.Errorf("FormName = %q, want %q", prop.FormName, tt.formName)
+			}
+			if prop.Column != tt.column {
+				t.Errorf("Column = %q, want %q", prop.Column, tt.column)
+			}
+			if prop.OmitEmpty != tt.omitEmpty {
+				t.Errorf("OmitEmpty = %v, want %v", prop.OmitEmpty, tt.omitEmpty)
+			}
+			if prop.JSONHidden != tt.jsonHidden {
+				t.Errorf("JSONHidden = %v, want %v", prop.JSONHidden, tt.jsonHidden)
+			}
+		})
+	}
+}
+
+func TestParseDefinitions(t *testing.T) {
+	p := parseFixture(t)
+
+	tests := []struct {
+		goType     string
+		properties []string
+	}{
+		{
+			goType: "coke/models.Widget",
+			// the fields of the embedded Timestamps are promoted and
+			// json:"-" fields are hidden
+			properties: []string{"id", "title", "description", "price", "status", "priority", "labels", "count", "tags", "owner_id", "owner", "dimensions", "retired", "created_at", "updated_at"},
+		},
+		{"coke/models.User", []string{"id", "name", "widgets"}},
+		{"coke/models.Dimensions", []string{"width", "height"}},
+		{"coke/models.Timestamps", []string{"created_at", "updated_at"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.goType, func(t *testing.T) {
+			def := findDefinition(t, p, tt.goType)
+			var properties []string
+			for _, prop := range def.Properties {
+				if !prop.JSONHidden {
+					properties = append(properties, prop.JSONName)
+				}
+			}
+			if !reflect.DeepEqual(properties, tt.properties) {
+				t.Errorf("properties = %q, want %q", properties, tt.properties)
+			}
+		})
+	}
+}
+
+func TestParsePropertyTypes(t *testing.T) {
+	p := parseFixture(t)
+	widget := findDefinition(t, p, "coke/models.Widget")
+
+	tests := []struct {
+		jsonName string
+		goType   string
+	}{
+		{"id", "github.com/gofrs/uuid.UUID"},
+		{"title", "string"},
+		{"price", "coke/models.Cents"},
+		{"labels", "github.com/gobuffalo/pop/v6/slices.String"},
+		{"tags", "[]coke/models.Tag"},
+		{"owner", "*coke/models.User"},
+		{"created_at", "time.Time"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.jsonName, func(t *testing.T) {
+			if prop := findProperty(t, widget, tt.jsonName); prop.Type != tt.goType {
+				t.Errorf("Type = %q, want %q", prop.Type, tt.goType)
+			}
+		})
+	}
+}
+
+func TestParseUnderlying(t *testing.T) {
+	p := parseFixture(t)
+
+	tests := []struct {
+		goType string
+		want   string
+	}{
+		{"coke/models.Cents", "int64"},
+		{"coke/models.Status", "string"},
+		{"coke/models.Priority", "int"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.goType, func(t *testing.T) {
+			if got := p.Underlying[tt.goType]; got != tt.want {
+				t.Errorf("Underlying[%s] = %q, want %q", tt.goType, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCanonicalType(t *testing.T) {
+	tests := []struct {
+		goType string
+		want   string
+	}{
+		{"github.com/gofrs/uuid/v5.UUID", "github.com/gofrs/uuid.UUID"},
+		{"github.com/gobuffalo/pop/v6/slices.String", "github.com/gobuffalo/pop/slices.String"},
+		{"github.com/gofrs/uuid.UUID", "github.com/gofrs/uuid.UUID"},
+		{"coke/models/v2dto.Widget", "coke/models/v2dto.Widget"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.goType, func(t *testing.T) {
+			if got := CanonicalType(tt.goType); got != tt.want {
+				t.Errorf("CanonicalType(%q) = %q, want %q", tt.goType, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseAssociations(t *testing.T) {
+	p := parseFixture(t)
+	widget := findDefinition(t, p, "coke/models.Widget")
+	user := findDefinition(t, p, "coke/models.User")
+
+	tests := []struct {
+		def      Definition
+		jsonName string
+		want     *Association
+	}{
+		// the foreign key is named by the json name of OwnerID
+		{widget, "owner", &Association{Kind: "belongs_to", ForeignKey: "owner_id"}},
+		{widget, "tags", &Association{Kind: "many_to_many", ForeignKey: "widget_id", JoinTable: "widget_tags"}},
+		{user, "widgets", &Association{Kind: "has_many", ForeignKey: "owner_id"}},
+		{widget, "owner_id", nil},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.def.Name)+"."+tt.jsonName, func(t *testing.T) {
+			prop := findProperty(t, tt.def, tt.jsonName)
+			if !reflect.DeepEqual(prop.Association, tt.want) {
+				t.Errorf("Association = %+v, want %+v", prop.Association, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseValidations(t *testing.T) {
+	p := parseFixture(t)
+	widget := findDefinition(t, p, "coke/models.Widget")
+
+	zero := 0.0
+	tests := []struct {
+		jsonName    string
+		constraints Constraints
+	}{
+		{"title", Constraints{Required: true, MinLength: Int64Ptr(3), MaxLength: Int64Ptr(64)}},
+		// IntIsGreaterThan{Field: int(w.Price)}
+		{"price", Constraints{Minimum: &zero, ExclusiveMinimum: true}},
+		{"status", Constraints{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.jsonName, func(t *testing.T) {
+			prop := findProperty(t, widget, tt.jsonName)
+			if !reflect.DeepEqual(prop.Constraints, tt.constraints) {
+				t.Errorf("Constraints = %+v, want %+v", prop.Constraints, tt.constraints)
+			}
+		})
+	}
+}
+
+func TestParseEnums(t *testing.T) {
+	p := parseFixture(t)
+
+	tests := []struct {
+		goType string
+		want   Enum
+	}{
+		{"coke/models.Status", Enum{
+			Type:       "coke/models.Status",
+			Underlying: "string",
+			Values:     []EnumValue{{"StatusActive", "active"}, {"StatusArchived", "archived"}},
+		}},
+		// iota constants are enums when the type has a String method
+		{"coke/models.Priority", Enum{
+			Type:       "coke/models.Priority",
+			Underlying: "int",
+			Values:     []EnumValue{{"PriorityLow", int64(0)}, {"PriorityHigh", int64(1)}},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.goType, func(t *testing.T) {
+			if got := p.Enums[tt.goType]; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Enums[%s] = %+v, want %+v", tt.goType, got, tt.want)
+			}
+		})
+	}
+	if _, ok := p.Enums["coke/models.Cents"]; ok {
+		t.Errorf("Cents has no constants but is an enum")
+	}
+}
+
+func TestParseSliceDefinitions(t *testing.T) {
+	p := parseFixture(t)
+
+	tests := []struct {
+		goType string
+		items  string
+	}{
+		{"coke/models.Widgets", "coke/models.Widget"},
+		{"coke/models.Users", "coke/models.User"},
+		{"coke/models.Tags", "coke/models.Tag"},
+		{"coke/models.Widget", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.goType, func(t *testing.T) {
+			if def := findDefinition(t, p, tt.goType); def.Items != tt.items {
+				t.Errorf("Items = %q, want %q", def.Items, tt.items)
+			}
+		})
+	}
+}
+
+func TestParseDescriptions(t *testing.T) {
+	p := parseFixture(t)
+
+	tests := []struct {
+		goType      string
+		description string
+	}{
+		{"coke/models.Widget", "Widget is a thing we sell."},
+		{"coke/models.Widgets", "Widgets is not required by pop and may be deleted"},
+		{"coke/models.Dimensions", "Dimensions of a widget in millimetres."},
+		{"coke/models.User", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.goType, func(t *testing.T) {
+			if def := findDefinition(t, p, tt.goType); def.Description != tt.description {
+				t.Errorf("Description = %q, want %q", def.Description, tt.description)
+			}
+		})
+	}
+
+	title := findProperty(t, findDefinition(t, p, "coke/models.Widget"), "title")
+	if want := "Title is shown in listings."; title.Description != want {
+		t.Errorf("title Description = %q, want %q", title.Description, want)
+	}
+	if want := "AdminStats counts the widgets of the last days."; findHandler(t, p, "AdminStats").Doc != want {
+		t.Errorf("AdminStats Doc = %q, want %q", findHandler(t, p, "AdminStats").Doc, want)
+	}
+}
+
+func TestParseReferencedDefinitions(t *testing.T) {
+	p := parseFixture(t)
+
+	tests := []struct {
+		goType     string
+		properties []string
+	}{
+		// APIError lives in the actions package and is rendered by handlers
+		{"coke/actions.APIError", []string{"code", "message"}},
+		// the major versions of the project's packages aren't dropped
+		{"coke/api/v1.Gadget", []string{"name"}},
+		{"coke/api/v2.Gadget", []string{"title"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.goType, func(t *testing.T) {
+			def := findDefinition(t, p, tt.goType)
+			var properties []string
+			for _, prop := range def.Properties {
+				if !prop.JSONHidden {
+					properties = append(properties, prop.JSONName)
+				}
+			}
+			if !reflect.DeepEqual(properties, tt.properties) {
+				t.Errorf("properties = %q, want %q", properties, tt.properties)
+			}
+		})
+	}
+}
+
+func TestNamedTypes(t *testing.T) {
+	tests := []struct {
+		goType string
+		want   []string
+	}{
+		{"string", nil},
+		{"coke/models.Widget", []string{"coke/models.Widget"}},
+		{"*coke/models.Widget", []string{"coke/models.Widget"}},
+		{"[]*coke/models.Widget", []string{"coke/models.Widget"}},
+		{"[4]coke/models.Widget", []string{"coke/models.Widget"}},
+		{"map[string][]coke/models.Tag", []string{"coke/models.Tag"}},
+		{"map[string]int", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.goType, func(t *testing.T) {
+			if got := namedTypes(tt.goType); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("namedTypes(%q) = %q, want %q", tt.goType, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSamePackage(t *testing.T) {
+	tests := []struct {
+		importPath string
+		pkgPath    string
+		want       bool
+	}{
+		{"coke/models", "coke/models", true},
+		// major versions of the project are different packages
+		{"coke/api/v2", "coke/api/v1", false},
+		// projects without go.mod name their packages after the directory
+		{"coke/models", "models", true},
+		{"coke/shared", "models", false},
+		{"coke/othermodels", "models", false},
+		{"coke/models", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.importPath+" "+tt.pkgPath, func(t *testing.T) {
+			if got := SamePackage(tt.importPath, tt.pkgPath); got != tt.want {
+				t.Errorf("SamePackage(%q, %q) = %v, want %v", tt.importPath, tt.pkgPath, got, tt.want)
+			}
+		})
+	}
+}
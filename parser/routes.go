@@ -0,0 +1,540 @@
+package parser
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/markbates/inflect"
+)
+
+// httpMethods are the buffalo.App methods that register a single route.
+var httpMethods = map[string]bool{
+	"GET":     true,
+	"POST":    true,
+	"PUT":     true,
+	"PATCH":   true,
+	"DELETE":  true,
+	"OPTIONS": true,
+	"HEAD":    true,
+}
+
+// resourceAction is a route buffalo registers for a Resource when the
+// resource implements the action.
+type resourceAction struct {
+	Action string
+	Method string
+	Path   string
+}
+
+// resourceActions mirrors buffalo.App.Resource, {id} is replaced with
+// the resource's param key.
+var resourceActions = []resourceAction{
+	{"List", "GET", "/"},
+	{"New", "GET", "/new"},
+	{"Show", "GET", "/{id}"},
+	{"Edit", "GET", "/{id}/edit"},
+	{"Create", "POST", "/"},
+	{"Update", "PUT", "/{id}"},
+	{"Update", "PATCH", "/{id}"},
+	{"Destroy", "DELETE", "/{id}"},
+}
+
+type Route struct {
+	Path       string
+	Method     string
+	Handler    string
+	Resource   string
+	Middleware []string
+	Position   token.Position
+
+	group *routeGroup
+}
+
+// routeGroup is a buffalo.App or a group created with Group or Resource.
+// Middleware is applied lazily by buffalo, so every route of a group gets
+// the group's final middleware stack.
+type routeGroup struct {
+	Prefix     string
+	Middleware []string
+	// Skips maps middleware to the handlers it is skipped for
+	Skips map[string][]string
+}
+
+// child creates a group the way buffalo.App.Group does, which clones the
+// current middleware stack including its skips.
+func (g *routeGroup) child(prefix string) *routeGroup {
+	skips := map[string][]string{}
+	for mw, handlers := range g.Skips {
+		skips[mw] = append([]string(nil), handlers...)
+	}
+	return &routeGroup{
+		Prefix:     joinPath(g.Prefix, prefix),
+		Middleware: append([]string(nil), g.Middleware...),
+		Skips:      skips,
+	}
+}
+
+// middlewareFor returns the middleware applied to a handler.
+func (g *routeGroup) middlewareFor(handler string) []string {
+	var middleware []string
+	for _, name := range g.Middleware {
+		if !containsString(g.Skips[name], handler) {
+			middleware = append(middleware, name)
+		}
+	}
+	return middleware
+}
+
+func (g *routeGroup) remove(middleware []string) {
+	var kept []string
+	for _, name := range g.Middleware {
+		if !containsString(middleware, name) {
+			kept = append(kept, name)
+		}
+	}
+	g.Middleware = kept
+}
+
+func (p *Parser) parseRoutes() error {
+	p.funcs = map[string]*ast.FuncDecl{}
+	p.scopes = map[string]fileScope{}
+	p.visiting = map[string]bool{}
+	p.ErrorHandlers = map[int]string{}
+
+	pkg, err := p.loadPackage("actions")
+	if err != nil {
+		return err
+	}
+
+	for _, f := range pkg.Files {
+		scope := newFileScope(pkg.Path, f)
+		for _, decl := range f.Decls {
+			if funcDecl, ok := decl.(*ast.FuncDecl); ok && funcDecl.Body != nil {
+				p.funcs[funcKey(funcDecl)] = funcDecl
+				p.scopes[funcKey(funcDecl)] = scope
+			}
+		}
+	}
+
+	if _, ok := p.funcs["App"]; !ok {
+		p.Diagnostics.Errorf(token.Position{}, "no func App in the actions package of %s", p.Project)
+		return nil
+	}
+	p.collectFuncRoutes("App", map[string]*routeGroup{
+		"app": &routeGroup{},
+	})
+
+	for i := range p.Routes {
+		p.Routes[i].Middleware = p.Routes[i].group.middlewareFor(p.Routes[i].Handler)
+	}
+	return nil
+}
+
+// collectFuncRoutes collects the routes of a function in the actions
+// package unless it is already being walked further up the call chain.
+func (p *Parser) collectFuncRoutes(name string, groups map[string]*routeGroup) {
+	if p.visiting[name] {
+		return
+	}
+	p.visiting[name] = true
+	defer delete(p.visiting, name)
+
+	p.collectRoutes(p.funcs[name].Body, groups)
+}
+
+// followCall walks into a helper function of the actions package when it
+// receives the app or a group as an argument.
+func (p *Parser) followCall(call *ast.CallExpr, groups map[string]*routeGroup, seen map[*ast.CallExpr]bool) {
+	ident, ok := call.Fun.(*ast.Ident)
+	if !ok {
+		return
+	}
+	funcDecl, ok := p.funcs[ident.Name]
+	if !ok || funcDecl.Recv != nil {
+		return
+	}
+
+	// the package level app stays visible unless a parameter shadows it
+	calleeGroups := map[string]*routeGroup{}
+	if app, ok := groups["app"]; ok {
+		calleeGroups["app"] = app
+	}
+
+	follow := false
+	i := 0
+	for _, field := range funcDecl.Type.Params.List {
+		for _, name := range field.Names {
+			if i >= len(call.Args) {
+				break
+			}
+			delete(calleeGroups, name.Name)
+			if group := p.groupOf(call.Args[i], groups, seen); group != nil {
+				calleeGroups[name.Name] = group
+				follow = true
+			}
+			i++
+		}
+		if len(field.Names) == 0 {
+			i++
+		}
+	}
+
+	if follow {
+		p.collectFuncRoutes(ident.Name, calleeGroups)
+	}
+}
+
+// collectRoutes walks the statements of a function body in source order,
+// records the routes registered on known groups and tracks the variables
+// new groups are assigned to.
+func (p *Parser) collectRoutes(body *ast.BlockStmt, groups map[string]*routeGroup) {
+	seen := map[*ast.CallExpr]bool{}
+	assign := func(lhs []ast.Expr, rhs []ast.Expr) {
+		for i, expr := range rhs {
+			// app.ErrorHandlers[404] = handler
+			if i < len(lhs) {
+				p.errorHandler(lhs[i], expr, groups)
+			}
+
+			call, ok := expr.(*ast.CallExpr)
+			if !ok || i >= len(lhs) {
+				continue
+			}
+			ident, ok := lhs[i].(*ast.Ident)
+			if !ok {
+				continue
+			}
+			if isBuffaloNew(call) {
+				groups[ident.Name] = &routeGroup{}
+				continue
+			}
+			if group := p.routeCall(call, groups, seen); group != nil {
+				groups[ident.Name] = group
+			}
+		}
+	}
+
+	ast.Inspect(body, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.AssignStmt:
+			assign(node.Lhs, node.Rhs)
+		case *ast.ValueSpec:
+			lhs := make([]ast.Expr, len(node.Names))
+			for i, name := range node.Names {
+				lhs[i] = name
+			}
+			assign(lhs, node.Values)
+		case *ast.CallExpr:
+			p.routeCall(node, groups, seen)
+			p.followCall(node, groups, seen)
+		}
+		return true
+	})
+}
+
+// routeCall handles a call on a group. Routes are appended to p.Routes and
+// the group created by Group or Resource calls is returned.
+func (p *Parser) routeCall(call *ast.CallExpr, groups map[string]*routeGroup, seen map[*ast.CallExpr]bool) *routeGroup {
+	if seen[call] {
+		return nil
+	}
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return nil
+	}
+
+	// g.Middleware.<Func>(...)
+	if mw, ok := sel.X.(*ast.SelectorExpr); ok && mw.Sel.Name == "Middleware" {
+		group := p.groupOf(mw.X, groups, seen)
+		if group == nil {
+			return nil
+		}
+		seen[call] = true
+		switch sel.Sel.Name {
+		case "Use":
+			group.Middleware = append(group.Middleware, exprStrings(call.Args)...)
+		case "Remove":
+			group.remove(exprStrings(call.Args))
+		case "Clear":
+			group.Middleware = nil
+		case "Skip":
+			if len(call.Args) > 1 {
+				mw := types.ExprString(call.Args[0])
+				if group.Skips == nil {
+					group.Skips = map[string][]string{}
+				}
+				for _, arg := range call.Args[1:] {
+					group.Skips[mw] = append(group.Skips[mw], p.handlerName(arg))
+				}
+			}
+		}
+		return nil
+	}
+
+	group := p.groupOf(sel.X, groups, seen)
+	if group == nil {
+		return nil
+	}
+	seen[call] = true
+
+	if sel.Sel.Name == "Use" {
+		group.Middleware = append(group.Middleware, exprStrings(call.Args)...)
+		return nil
+	}
+
+	if len(call.Args) < 1 {
+		return nil
+	}
+	routePath, ok := stringLiteral(call.Args[0])
+	if !ok {
+		if httpMethods[sel.Sel.Name] || sel.Sel.Name == "Group" || sel.Sel.Name == "Resource" {
+			p.Diagnostics.Warnf(p.fset.Position(call.Pos()), "skipping %s with a path that isn't a string literal", sel.Sel.Name)
+		}
+		return nil
+	}
+
+	switch {
+	case httpMethods[sel.Sel.Name] && len(call.Args) > 1:
+		p.Routes = append(p.Routes, Route{
+			Path:     joinPath(group.Prefix, routePath),
+			Method:   sel.Sel.Name,
+			Handler:  p.handlerName(call.Args[1]),
+			Position: p.fset.Position(call.Pos()),
+			group:    group,
+		})
+	case sel.Sel.Name == "Group":
+		return group.child(routePath)
+	case sel.Sel.Name == "Resource" && len(call.Args) > 1:
+		resource := group.child(routePath)
+		// routes added to the returned group are nested in a member
+		if paramKey := p.addResource(resource, call); paramKey != "" {
+			resource.Prefix = joinPath(resource.Prefix, "{"+paramKey+"}")
+		}
+		return resource
+	}
+	return nil
+}
+
+// errorHandler records an app.ErrorHandlers[status] = handler assignment.
+// The ErrorHandlers map is shared by all groups of an app.
+func (p *Parser) errorHandler(lhs ast.Expr, rhs ast.Expr, groups map[string]*routeGroup) {
+	index, ok := lhs.(*ast.IndexExpr)
+	if !ok {
+		return
+	}
+	sel, ok := index.X.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != "ErrorHandlers" {
+		return
+	}
+	if recv, ok := sel.X.(*ast.Ident); !ok || groups[recv.Name] == nil {
+		return
+	}
+	if status := p.statusCode(index.Index); status != 0 {
+		p.ErrorHandlers[status] = p.handlerName(rhs)
+	}
+}
+
+// groupOf resolves the group an expression refers to, either a variable
+// or an inline Group/Resource call.
+func (p *Parser) groupOf(expr ast.Expr, groups map[string]*routeGroup, seen map[*ast.CallExpr]bool) *routeGroup {
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		return groups[expr.Name]
+	case *ast.ParenExpr:
+		return p.groupOf(expr.X, groups, seen)
+	case *ast.CallExpr:
+		return p.routeCall(expr, groups, seen)
+	}
+	return nil
+}
+
+// addResource expands app.Resource(path, resource) into the routes of
+// the actions the resource type implements and returns the resource's
+// param key.
+func (p *Parser) addResource(group *routeGroup, call *ast.CallExpr) string {
+	typeName := p.resourceTypeName(call.Args[1])
+	if typeName == "" {
+		p.Diagnostics.Warnf(p.fset.Position(call.Pos()), "can't determine the type of resource %s", types.ExprString(call.Args[1]))
+		return ""
+	}
+
+	resourceName := strings.TrimSuffix(typeName, "Resource")
+	paramKey := inflect.Name(resourceName).ParamID()
+	if key, ok := p.resourceParamKey(typeName); ok {
+		paramKey = key
+	}
+
+	found := false
+	for _, action := range resourceActions {
+		handler := typeName + "." + action.Action
+		if _, ok := p.funcs[handler]; !ok {
+			continue
+		}
+		p.Routes = append(p.Routes, Route{
+			Path:     joinPath(group.Prefix, strings.Replace(action.Path, "{id}", "{"+paramKey+"}", -1)),
+			Method:   action.Method,
+			Handler:  handler,
+			Resource: resourceName,
+			Position: p.fset.Position(call.Pos()),
+			group:    group,
+		})
+		found = true
+	}
+	if !found {
+		p.Diagnostics.Warnf(p.fset.Position(call.Pos()), "resource %s implements none of the resource actions", typeName)
+	}
+	return paramKey
+}
+
+// resourceParamKey returns the value of a ParamKey() method returning a
+// constant string, which buffalo uses instead of <name>_id.
+func (p *Parser) resourceParamKey(typeName string) (string, bool) {
+	funcDecl, ok := p.funcs[typeName+".ParamKey"]
+	if !ok || len(funcDecl.Body.List) != 1 {
+		return "", false
+	}
+	ret, ok := funcDecl.Body.List[0].(*ast.ReturnStmt)
+	if !ok || len(ret.Results) != 1 {
+		return "", false
+	}
+	return stringLiteral(ret.Results[0])
+}
+
+// resourceTypeName returns the type name of a resource expression like
+// WidgetsResource{}, &WidgetsResource{} or a variable holding one.
+func (p *Parser) resourceTypeName(expr ast.Expr) string {
+	if paren, ok := expr.(*ast.ParenExpr); ok {
+		return p.resourceTypeName(paren.X)
+	}
+	if typeName := literalTypeName(expr); typeName != "" {
+		return typeName
+	}
+	ident, ok := expr.(*ast.Ident)
+	if !ok {
+		return ""
+	}
+	if goType, ok := p.infoType(ident); ok {
+		return UnqualifiedName(strings.TrimPrefix(goType, "*"))
+	}
+
+	// variables declared in the same function
+	if ident.Obj == nil || ident.Obj.Kind != ast.Var {
+		return ""
+	}
+	switch decl := ident.Obj.Decl.(type) {
+	case *ast.AssignStmt:
+		for i, lhs := range decl.Lhs {
+			if name, ok := lhs.(*ast.Ident); ok && name.Name == ident.Name && len(decl.Lhs) == len(decl.Rhs) {
+				return p.resourceTypeName(decl.Rhs[i])
+			}
+		}
+	case *ast.ValueSpec:
+		if decl.Type != nil {
+			typeExpr := decl.Type
+			if star, ok := typeExpr.(*ast.StarExpr); ok {
+				typeExpr = star.X
+			}
+			if name, ok := typeExpr.(*ast.Ident); ok {
+				return name.Name
+			}
+			return ""
+		}
+		for i, name := range decl.Names {
+			if name.Name == ident.Name && len(decl.Names) == len(decl.Values) {
+				return p.resourceTypeName(decl.Values[i])
+			}
+		}
+	}
+	return ""
+}
+
+// literalTypeName returns the type name of a composite literal like
+// WidgetsResource{} or &WidgetsResource{}.
+func literalTypeName(expr ast.Expr) string {
+	if unary, ok := expr.(*ast.UnaryExpr); ok && unary.Op == token.AND {
+		expr = unary.X
+	}
+	if lit, ok := expr.(*ast.CompositeLit); ok {
+		if ident, ok := lit.Type.(*ast.Ident); ok {
+			return ident.Name
+		}
+	}
+	return ""
+}
+
+// handlerName returns the handler identifier of an expression, resource
+// methods like WidgetsResource{}.List or wr.List are named
+// WidgetsResource.List.
+func (p *Parser) handlerName(expr ast.Expr) string {
+	if sel, ok := expr.(*ast.SelectorExpr); ok {
+		if typeName := p.resourceTypeName(sel.X); typeName != "" {
+			return typeName + "." + sel.Sel.Name
+		}
+	}
+	return types.ExprString(expr)
+}
+
+// funcKey returns Name for functions and Type.Name for methods.
+func funcKey(funcDecl *ast.FuncDecl) string {
+	if funcDecl.Recv == nil || len(funcDecl.Recv.List) == 0 {
+		return funcDecl.Name.Name
+	}
+	recv := funcDecl.Recv.List[0].Type
+	if star, ok := recv.(*ast.StarExpr); ok {
+		recv = star.X
+	}
+	if index, ok := recv.(*ast.IndexExpr); ok {
+		recv = index.X
+	}
+	return types.ExprString(recv) + "." + funcDecl.Name.Name
+}
+
+// isBuffaloNew reports whether call is buffalo.New(...).
+func isBuffaloNew(call *ast.CallExpr) bool {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != "New" {
+		return false
+	}
+	pkg, ok := sel.X.(*ast.Ident)
+	return ok && pkg.Name == "buffalo"
+}
+
+func exprStrings(exprs []ast.Expr) []string {
+	var values []string
+	for _, expr := range exprs {
+		values = append(values, types.ExprString(expr))
+	}
+	return values
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// stringLiteral returns the value of a string literal expression.
+func stringLiteral(expr ast.Expr) (string, bool) {
+	lit, ok := expr.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", false
+	}
+	value, err := strconv.Unquote(lit.Value)
+	if err != nil {
+		return "", false
+	}
+	return value, true
+}
+
+// joinPath joins a route prefix and path the way buffalo does, without
+// the trailing slash buffalo appends for its own router.
+func joinPath(prefix, routePath string) string {
+	return path.Join("/", prefix, routePath)
+}
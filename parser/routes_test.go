@@ -0,0 +1,263 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func findRoute(t *testing.T, p *Parser, method string, routePath string) Route {
+	t.Helper()
+	for _, route := range p.Routes {
+		if route.Method == method && route.Path == routePath {
+			return route
+		}
+	}
+	t.Fatalf("route %s %s not found", method, routePath)
+	return Route{}
+}
+
+func TestParseRoutes(t *testing.T) {
+	p := parseFixture(t)
+
+	tests := []struct {
+		method  string
+		path    string
+		handler string
+	}{
+		{"GET", "/", "HomeHandler"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			route := findRoute(t, p, tt.method, tt.path)
+			if route.Handler != tt.handler {
+				t.Errorf("Handler = %q, want %q", route.Handler, tt.handler)
+			}
+		})
+	}
+}
+
+func TestParseResources(t *testing.T) {
+	p := parseFixture(t)
+
+	tests := []struct {
+		method   string
+		path     string
+		handler  string
+		resource string
+	}{
+		{"GET", "/widgets", "WidgetsResource.List", "Widgets"},
+		{"GET", "/widgets/{widget_id}", "WidgetsResource.Show", "Widgets"},
+		{"POST", "/widgets", "WidgetsResource.Create", "Widgets"},
+		{"PUT", "/widgets/{widget_id}", "WidgetsResource.Update", "Widgets"},
+		{"PATCH", "/widgets/{widget_id}", "WidgetsResource.Update", "Widgets"},
+		{"DELETE", "/widgets/{widget_id}", "WidgetsResource.Destroy", "Widgets"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			route := findRoute(t, p, tt.method, tt.path)
+			if route.Handler != tt.handler {
+				t.Errorf("Handler = %q, want %q", route.Handler, tt.handler)
+			}
+			if route.Resource != tt.resource {
+				t.Errorf("Resource = %q, want %q", route.Resource, tt.resource)
+			}
+		})
+	}
+
+	// WidgetsResource has no New and Edit actions
+	for _, route := range p.Routes {
+		if route.Path == "/widgets/new" || route.Path == "/widgets/{widget_id}/edit" {
+			t.Errorf("unexpected route %s %s", route.Method, route.Path)
+		}
+	}
+}
+
+func TestParseResourceVariables(t *testing.T) {
+	// without a go.mod the types of the variables are read from the syntax
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "actions", "app.go"), `package actions
+
+import "github.com/gobuffalo/buffalo"
+
+func App() *buffalo.App {
+	app := buffalo.New(buffalo.Options{})
+	var ar AccountsResource
+	app.Resource("/accounts", ar)
+	er := &EmptyResource{}
+	app.Resource("/empty", er)
+	return app
+}
+
+type AccountsResource struct{}
+
+func (AccountsResource) List(c buffalo.Context) error { return nil }
+
+type EmptyResource struct{}
+`)
+	p := NewParser(dir)
+	diagnostics, err := p.ParseProject()
+	if err != nil {
+		t.Fatalf("ParseProject() error = %v", err)
+	}
+
+	route := findRoute(t, p, "GET", "/accounts")
+	if route.Handler != "AccountsResource.List" {
+		t.Errorf("Handler = %q, want %q", route.Handler, "AccountsResource.List")
+	}
+	found := false
+	for _, d := range diagnostics {
+		found = found || (d.Severity == Warning && strings.Contains(d.Message, "resource EmptyResource implements none of the resource actions"))
+	}
+	if !found {
+		t.Errorf("diagnostics = %v, want a warning for EmptyResource", diagnostics)
+	}
+}
+
+func writeFile(t *testing.T, name string, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(name, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParseGroups(t *testing.T) {
+	p := parseFixture(t)
+
+	tests := []struct {
+		method  string
+		path    string
+		handler string
+	}{
+		{"GET", "/api/v1/status", "StatusHandler"},
+		{"GET", "/api/v1/tags", "TagsResource.List"},
+		{"GET", "/api/v1/tags/{tag_id}", "TagsResource.Show"},
+		// routes of the group returned by Resource are nested in a member
+		{"GET", "/api/v1/tags/{tag_id}/widgets", "TagWidgets"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			route := findRoute(t, p, tt.method, tt.path)
+			if route.Handler != tt.handler {
+				t.Errorf("Handler = %q, want %q", route.Handler, tt.handler)
+			}
+		})
+	}
+}
+
+func TestJoinPath(t *testing.T) {
+	tests := []struct {
+		prefix    string
+		routePath string
+		want      string
+	}{
+		{"", "/", "/"},
+		{"", "/widgets", "/widgets"},
+		{"/api/v1", "/status", "/api/v1/status"},
+		{"/api/v1/", "status/", "/api/v1/status"},
+		{"/tags/{tag_id}", "/widgets", "/tags/{tag_id}/widgets"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.prefix+" "+tt.routePath, func(t *testing.T) {
+			if got := joinPath(tt.prefix, tt.routePath); got != tt.want {
+				t.Errorf("joinPath(%q, %q) = %q, want %q", tt.prefix, tt.routePath, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseHelperRoutes(t *testing.T) {
+	p := parseFixture(t)
+
+	// registerAdminRoutes receives the group as an argument
+	route := findRoute(t, p, "GET", "/api/v1/admin/stats")
+	if route.Handler != "AdminStats" {
+		t.Errorf("Handler = %q, want AdminStats", route.Handler)
+	}
+}
+
+func TestParseMiddleware(t *testing.T) {
+	p := parseFixture(t)
+
+	tests := []struct {
+		method     string
+		path       string
+		middleware []string
+	}{
+		// HomeHandler is skipped by Authorize
+		{"GET", "/", nil},
+		{"GET", "/widgets", []string{"Authorize"}},
+		// WidgetsResource.Show is skipped through the wr variable
+		{"GET", "/widgets/{widget_id}", nil},
+		{"DELETE", "/widgets/{widget_id}", []string{"Authorize"}},
+		{"GET", "/api/v1/status", []string{"Authorize", "APIKey"}},
+		{"GET", "/api/v1/tags/{tag_id}/widgets", []string{"Authorize", "APIKey"}},
+		{"GET", "/api/v1/admin/stats", []string{"Authorize", "APIKey"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			route := findRoute(t, p, tt.method, tt.path)
+			if !reflect.DeepEqual(route.Middleware, tt.middleware) {
+				t.Errorf("Middleware = %q, want %q", route.Middleware, tt.middleware)
+			}
+		})
+	}
+}
+
+func TestRouteGroups(t *testing.T) {
+	tests := []struct {
+		name       string
+		group      *routeGroup
+		prefix     string
+		handler    string
+		wantPrefix string
+		wantMW     []string
+	}{
+		{
+			name:       "nested prefix",
+			group:      &routeGroup{Prefix: "/api/v1"},
+			prefix:     "/admin",
+			wantPrefix: "/api/v1/admin",
+		},
+		{
+			name:       "inherited middleware",
+			group:      &routeGroup{Middleware: []string{"Authorize"}},
+			prefix:     "/api",
+			handler:    "StatusHandler",
+			wantPrefix: "/api",
+			wantMW:     []string{"Authorize"},
+		},
+		{
+			name: "inherited skips",
+			group: &routeGroup{
+				Middleware: []string{"Authorize", "APIKey"},
+				Skips:      map[string][]string{"Authorize": {"HomeHandler"}},
+			},
+			prefix:     "/",
+			handler:    "HomeHandler",
+			wantPrefix: "/",
+			wantMW:     []string{"APIKey"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			child := tt.group.child(tt.prefix)
+			if child.Prefix != tt.wantPrefix {
+				t.Errorf("Prefix = %q, want %q", child.Prefix, tt.wantPrefix)
+			}
+			if got := child.middlewareFor(tt.handler); !reflect.DeepEqual(got, tt.wantMW) {
+				t.Errorf("middlewareFor(%q) = %q, want %q", tt.handler, got, tt.wantMW)
+			}
+			// the child's stack is a copy
+			child.Middleware = append(child.Middleware, "Extra")
+			if containsString(tt.group.Middleware, "Extra") {
+				t.Errorf("middleware of the child leaked into the parent")
+			}
+		})
+	}
+}
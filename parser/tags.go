@@ -0,0 +1,157 @@
+package parser
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// associationTags are the struct tags pop declares associations with.
+var associationTags = []string{"has_many", "belongs_to", "has_one", "many_to_many"}
+
+// overrideTags are the struct tags overriding schema details, the swagger
+// tag wins over the openapi tag.
+var overrideTags = []string{"openapi", "swagger"}
+
+// Overrides are the schema details set with the swagger or openapi tag.
+type Overrides struct {
+	Description string
+	// Example is converted to the type of the schema by the generator
+	Example    string
+	Format     string
+	ReadOnly   bool
+	WriteOnly  bool
+	Deprecated bool
+	// Min and Max bound the value of numbers, the length of strings and
+	// the number of items of arrays
+	Min *float64
+	Max *float64
+
+	// invalid describes the options that couldn't be read
+	invalid []string
+}
+
+// tagOptions splits a struct tag value into its name and options.
+func tagOptions(value string) (string, []string) {
+	parts := strings.Split(value, ",")
+	return parts[0], parts[1:]
+}
+
+// newProperty reads the json, db and form tags of a struct field. It
+// returns false for fields hidden with the swagger or openapi tag.
+func newProperty(name Name, goType string, tag reflect.StructTag) (Property, bool) {
+	prop := Property{
+		Name:     name,
+		Type:     goType,
+		Tag:      string(tag),
+		JSONName: string(name),
+		Column:   string(name),
+		FormName: string(name),
+	}
+
+	if value, ok := tag.Lookup("json"); ok {
+		jsonName, options := tagOptions(value)
+		if value == "-" {
+			prop.JSONHidden = true
+		} else if jsonName != "" {
+			prop.JSONName = jsonName
+		}
+		for _, option := range options {
+			switch option {
+			case "omitempty":
+				prop.OmitEmpty = true
+			case "string":
+				prop.AsString = true
+			}
+		}
+	}
+
+	if value, ok := tag.Lookup("db"); ok {
+		prop.Column, _ = tagOptions(value)
+	}
+	if value, ok := tag.Lookup("form"); ok {
+		prop.FormName, _ = tagOptions(value)
+	}
+
+	for _, key := range overrideTags {
+		if value, ok := tag.Lookup(key); ok {
+			if !prop.Overrides.parse(value) {
+				return prop, false
+			}
+		}
+	}
+
+	for _, kind := range associationTags {
+		if value, ok := tag.Lookup(kind); ok {
+			prop.Association = &Association{
+				Kind:       kind,
+				ForeignKey: tag.Get("fk_id"),
+			}
+			if kind == "many_to_many" {
+				prop.Association.JoinTable = value
+				prop.Association.ForeignKey = tag.Get("primary_id")
+			}
+		}
+	}
+	return prop, true
+}
+
+// parse reads a tag value like "description='Owner, if any',readOnly". It
+// returns false for "-", which hides the field.
+func (o *Overrides) parse(value string) bool {
+	if value == "-" {
+		return false
+	}
+	for _, option := range splitOptions(value) {
+		key, val, _ := strings.Cut(option, "=")
+		val = strings.Trim(val, "'")
+		switch strings.TrimSpace(key) {
+		case "description":
+			o.Description = val
+		case "example":
+			o.Example = val
+		case "format":
+			o.Format = val
+		case "readOnly":
+			o.ReadOnly = true
+		case "writeOnly":
+			o.WriteOnly = true
+		case "deprecated":
+			o.Deprecated = true
+		case "min":
+			if min, err := strconv.ParseFloat(val, 64); err == nil {
+				o.Min = &min
+			} else {
+				o.invalid = append(o.invalid, fmt.Sprintf("min %q isn't a number", val))
+			}
+		case "max":
+			if max, err := strconv.ParseFloat(val, 64); err == nil {
+				o.Max = &max
+			} else {
+				o.invalid = append(o.invalid, fmt.Sprintf("max %q isn't a number", val))
+			}
+		case "":
+		default:
+			o.invalid = append(o.invalid, fmt.Sprintf("unknown tag option %q", strings.TrimSpace(key)))
+		}
+	}
+	return true
+}
+
+// splitOptions splits a tag value at the commas outside of single quotes.
+func splitOptions(value string) []string {
+	var options []string
+	quoted := false
+	start := 0
+	for i, c := range value {
+		switch {
+		case c == '\'':
+			quoted = !quoted
+		case c == ',' && !quoted:
+			options = append(options, value[start:i])
+			start = i + 1
+		}
+	}
+	return append(options, value[start:])
+}
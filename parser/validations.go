@@ -0,0 +1,245 @@
+package parser
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"strings"
+)
+
+// validatorsPath is the import path of the gobuffalo/validate validators.
+const validatorsPath = "github.com/gobuffalo/validate/validators"
+
+// Constraints are the restrictions the validators of a model's Validate
+// method put on a property.
+type Constraints struct {
+	Required         bool
+	Format           string
+	Pattern          string
+	MinLength        *int64
+	MaxLength        *int64
+	Minimum          *float64
+	Maximum          *float64
+	ExclusiveMinimum bool
+	ExclusiveMaximum bool
+	MinItems         *int64
+	Enum             []string
+}
+
+// parseValidations reads the validator literals of the Validate methods of
+// the models into the constraints of their properties.
+func (p *Parser) parseValidations(pkg *sourcePackage) {
+	for _, f := range pkg.Files {
+		scope := newFileScope(pkg.Path, f)
+		for _, decl := range f.Decls {
+			funcDecl, ok := decl.(*ast.FuncDecl)
+			if !ok || funcDecl.Recv == nil || funcDecl.Body == nil || funcDecl.Name.Name != "Validate" {
+				continue
+			}
+			recv := funcDecl.Recv.List[0]
+			typeName, ok := unstar(recv.Type).(*ast.Ident)
+			if !ok || len(recv.Names) == 0 {
+				continue
+			}
+			def := p.definition(pkg.Path + "." + typeName.Name)
+			if def == nil {
+				continue
+			}
+
+			ast.Inspect(funcDecl.Body, func(n ast.Node) bool {
+				lit, ok := n.(*ast.CompositeLit)
+				if !ok || lit.Type == nil {
+					return true
+				}
+				validator := CanonicalType(p.typeOf(lit.Type, scope))
+				if !strings.HasPrefix(validator, validatorsPath+".") {
+					return true
+				}
+				fields := map[string]ast.Expr{}
+				for _, elt := range lit.Elts {
+					if kv, ok := elt.(*ast.KeyValueExpr); ok {
+						if key, ok := kv.Key.(*ast.Ident); ok {
+							fields[key.Name] = kv.Value
+						}
+					}
+				}
+				field := receiverField(fields["Field"], recv.Names[0].Name)
+				for i := range def.Properties {
+					if string(def.Properties[i].Name) == field {
+						p.applyValidator(&def.Properties[i].Constraints, strings.TrimPrefix(validator, validatorsPath+"."), fields)
+					}
+				}
+				return false
+			})
+		}
+	}
+}
+
+// applyValidator translates a validator into constraints.
+func (p *Parser) applyValidator(c *Constraints, validator string, fields map[string]ast.Expr) {
+	switch validator {
+	case "StringIsPresent":
+		c.Required = true
+		c.MinLength = Int64Ptr(1)
+	case "StringLengthInRange":
+		// a zero bound isn't checked
+		if min, ok := p.intValue(fields["Min"]); ok && min > 0 {
+			c.MinLength = Int64Ptr(min)
+		}
+		if max, ok := p.intValue(fields["Max"]); ok && max > 0 {
+			c.MaxLength = Int64Ptr(max)
+		}
+	case "EmailIsPresent":
+		c.Required = true
+		c.Format = "email"
+	case "EmailLike":
+		c.Format = "email"
+	case "URLIsPresent":
+		c.Required = true
+		c.Format = "uri"
+	case "RegexMatch":
+		if expr, ok := p.stringValue(fields["Expr"]); ok {
+			c.Pattern = expr
+		}
+	case "IntIsGreaterThan":
+		if compared, ok := p.intValue(fields["Compared"]); ok {
+			min := float64(compared)
+			c.Minimum = &min
+			c.ExclusiveMinimum = true
+		}
+	case "IntIsLessThan":
+		if compared, ok := p.intValue(fields["Compared"]); ok {
+			max := float64(compared)
+			c.Maximum = &max
+			c.ExclusiveMaximum = true
+		}
+	case "IntArrayIsPresent":
+		c.Required = true
+		c.MinItems = Int64Ptr(1)
+	case "StringInclusion":
+		if list, ok := fields["List"].(*ast.CompositeLit); ok {
+			c.Enum = nil
+			for _, elt := range list.Elts {
+				if value, ok := p.stringValue(elt); ok {
+					c.Enum = append(c.Enum, value)
+				}
+			}
+		}
+	default:
+		if strings.HasSuffix(validator, "IsPresent") || strings.HasSuffix(validator, "ArePresent") {
+			c.Required = true
+		}
+	}
+}
+
+// definition returns the definition of a type by its qualified name.
+func (p *Parser) definition(goType string) *Definition {
+	for i := range p.Definitions {
+		if p.Definitions[i].GoType() == goType {
+			return &p.Definitions[i]
+		}
+	}
+	return nil
+}
+
+// receiverField returns the field of the receiver a validator checks,
+// conversions like int(w.Count) are looked through.
+func receiverField(expr ast.Expr, recv string) string {
+	if call, ok := expr.(*ast.CallExpr); ok && len(call.Args) == 1 {
+		expr = call.Args[0]
+	}
+	sel, ok := expr.(*ast.SelectorExpr)
+	if !ok {
+		return ""
+	}
+	if x, ok := sel.X.(*ast.Ident); ok && x.Name == recv {
+		return sel.Sel.Name
+	}
+	return ""
+}
+
+func unstar(expr ast.Expr) ast.Expr {
+	if star, ok := expr.(*ast.StarExpr); ok {
+		return star.X
+	}
+	return expr
+}
+
+// constantValue returns the value of a constant expression, or nil when it
+// can't be determined.
+func (p *Parser) constantValue(expr ast.Expr) constant.Value {
+	return p.evalConstant(expr, nil)
+}
+
+// evalConstant evaluates a constant expression with the value of iota of
+// the const spec it belongs to.
+func (p *Parser) evalConstant(expr ast.Expr, iota constant.Value) constant.Value {
+	if expr == nil {
+		return nil
+	}
+	if p.info != nil {
+		if tv, ok := p.info.Types[expr]; ok && tv.Value != nil {
+			return tv.Value
+		}
+	}
+
+	switch expr := expr.(type) {
+	case *ast.BasicLit:
+		return constant.MakeFromLiteral(expr.Value, expr.Kind, 0)
+	case *ast.Ident:
+		if expr.Name == "iota" && iota != nil {
+			return iota
+		}
+		// constants declared in the same file
+		if expr.Obj != nil && expr.Obj.Kind == ast.Con {
+			if spec, ok := expr.Obj.Decl.(*ast.ValueSpec); ok {
+				for i, name := range spec.Names {
+					if name.Name == expr.Name && i < len(spec.Values) {
+						return p.evalConstant(spec.Values[i], nil)
+					}
+				}
+			}
+		}
+	case *ast.ParenExpr:
+		return p.evalConstant(expr.X, iota)
+	case *ast.UnaryExpr:
+		if value := p.evalConstant(expr.X, iota); value != nil && (expr.Op == token.SUB || expr.Op == token.ADD) {
+			return constant.UnaryOp(expr.Op, value, 0)
+		}
+	case *ast.BinaryExpr:
+		x, y := p.evalConstant(expr.X, iota), p.evalConstant(expr.Y, iota)
+		if x == nil || y == nil {
+			return nil
+		}
+		switch expr.Op {
+		case token.ADD, token.SUB, token.MUL:
+			return constant.BinaryOp(x, expr.Op, y)
+		case token.SHL, token.SHR:
+			if s, ok := constant.Uint64Val(y); ok {
+				return constant.Shift(x, expr.Op, uint(s))
+			}
+		}
+	}
+	return nil
+}
+
+func (p *Parser) intValue(expr ast.Expr) (int64, bool) {
+	value := p.constantValue(expr)
+	if value == nil {
+		return 0, false
+	}
+	return constant.Int64Val(constant.ToInt(value))
+}
+
+func (p *Parser) stringValue(expr ast.Expr) (string, bool) {
+	value := p.constantValue(expr)
+	if value == nil || value.Kind() != constant.String {
+		return "", false
+	}
+	return constant.StringVal(value), true
+}
+
+// Int64Ptr returns a pointer to i for the optional bounds of constraints.
+func Int64Ptr(i int64) *int64 {
+	return &i
+}
@@ -0,0 +1,20 @@
+package actions
+
+import (
+	"net/http"
+
+	dto "coke/internal/dto/api"
+	"coke/models/api"
+
+	"github.com/gobuffalo/buffalo"
+)
+
+// ModelAccount shows the stored account.
+func ModelAccount(c buffalo.Context) error {
+	return c.Render(http.StatusOK, r.JSON(api.Account{}))
+}
+
+// DTOAccount shows the account sent to API clients.
+func DTOAccount(c buffalo.Context) error {
+	return c.Render(http.StatusOK, r.JSON(dto.Account{}))
+}
@@ -0,0 +1,68 @@
+package actions
+
+import (
+	"github.com/gobuffalo/buffalo"
+)
+
+var app *buffalo.App
+
+// App is where all routes and middleware for buffalo
+// should be defined. This is the nerve center of your
+// application.
+func App() *buffalo.App {
+	if app == nil {
+		app = buffalo.New(buffalo.Options{
+			Env:         "test",
+			SessionName: "_coke_session",
+		})
+
+		app.Use(Authorize)
+		app.Middleware.Skip(Authorize, HomeHandler)
+
+		app.GET("/", HomeHandler)
+		wr := WidgetsResource{}
+		widgets := app.Resource("/widgets", wr)
+		widgets.Middleware.Skip(Authorize, wr.Show)
+
+		api := app.Group("/api/v1")
+		api.Use(APIKey)
+		api.GET("/status", StatusHandler)
+		tags := api.Resource("/tags", TagsResource{})
+		tags.GET("/widgets", TagWidgets)
+
+		registerAdminRoutes(api.Group("/admin"))
+
+		app.GET("/v1/gadget", V1Gadget)
+		app.GET("/v2/gadget", V2Gadget)
+		app.GET("/account", ModelAccount)
+		app.GET("/dto/account", DTOAccount)
+
+		app.ErrorHandlers[500] = customError
+	}
+
+	return app
+}
+
+func registerAdminRoutes(admin *buffalo.App) {
+	admin.GET("/stats", AdminStats)
+}
+
+// Authorize requires a session.
+func Authorize(next buffalo.Handler) buffalo.Handler {
+	return next
+}
+
+// APIKey requires an api key header.
+func APIKey(next buffalo.Handler) buffalo.Handler {
+	return next
+}
+
+// APIError is the body of failed requests.
+type APIError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func customError(status int, err error, c buffalo.Context) error {
+	return c.Render(status, r.JSON(APIError{Code: status, Message: err.Error()}))
+}
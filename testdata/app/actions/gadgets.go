@@ -0,0 +1,20 @@
+package actions
+
+import (
+	"net/http"
+
+	v1 "coke/api/v1"
+	v2 "coke/api/v2"
+
+	"github.com/gobuffalo/buffalo"
+)
+
+// V1Gadget shows a gadget of the first API version.
+func V1Gadget(c buffalo.Context) error {
+	return c.Render(http.StatusOK, r.JSON(v1.Gadget{}))
+}
+
+// V2Gadget shows a gadget of the second API version.
+func V2Gadget(c buffalo.Context) error {
+	return c.Render(http.StatusOK, r.JSON(v2.Gadget{}))
+}
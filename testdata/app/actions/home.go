@@ -0,0 +1,36 @@
+package actions
+
+import (
+	"net/http"
+	"strconv"
+
+	"coke/models"
+
+	"github.com/gobuffalo/buffalo"
+)
+
+// HomeHandler is a default handler to serve up
+// a home page.
+func HomeHandler(c buffalo.Context) error {
+	return c.Render(http.StatusOK, r.String("Welcome to Buffalo"))
+}
+
+// StatusHandler reports the health of the service.
+func StatusHandler(c buffalo.Context) error {
+	return c.Render(http.StatusOK, r.JSON(map[string]string{"status": "ok"}))
+}
+
+// AdminStats counts the widgets of the last days.
+func AdminStats(c buffalo.Context) error {
+	days, err := strconv.Atoi(c.Param("days"))
+	if err != nil {
+		return c.Error(http.StatusBadRequest, err)
+	}
+	return c.Render(http.StatusOK, r.JSON(map[string]int{"days": days}))
+}
+
+// TagWidgets lists the widgets of a tag.
+func TagWidgets(c buffalo.Context) error {
+	widgets := models.Widgets{}
+	return c.Render(http.StatusOK, r.JSON(widgets))
+}
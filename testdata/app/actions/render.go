@@ -0,0 +1,11 @@
+package actions
+
+import (
+	"github.com/gobuffalo/buffalo/render"
+)
+
+var r *render.Engine
+
+func init() {
+	r = render.New(render.Options{})
+}
@@ -0,0 +1,26 @@
+package actions
+
+import (
+	"net/http"
+
+	"coke/models"
+
+	"github.com/gobuffalo/buffalo"
+)
+
+// TagsResource is the resource for the Tag model
+type TagsResource struct {
+	buffalo.Resource
+}
+
+// List gets all Tags.
+func (v TagsResource) List(c buffalo.Context) error {
+	tags := models.Tags{}
+	return c.Render(http.StatusOK, r.JSON(tags))
+}
+
+// Show gets the data for one Tag.
+func (v TagsResource) Show(c buffalo.Context) error {
+	tag := models.Tag{}
+	return c.Render(http.StatusOK, r.JSON(tag))
+}
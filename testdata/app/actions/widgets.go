@@ -0,0 +1,66 @@
+package actions
+
+import (
+	"fmt"
+	"net/http"
+
+	"coke/models"
+
+	"github.com/gobuffalo/buffalo"
+	"github.com/gofrs/uuid"
+)
+
+// WidgetsResource is the resource for the Widget model
+type WidgetsResource struct {
+	buffalo.Resource
+}
+
+// List gets all Widgets. This function is mapped to the path
+// GET /widgets
+func (v WidgetsResource) List(c buffalo.Context) error {
+	widgets := &models.Widgets{}
+	return c.Render(http.StatusOK, r.JSON(widgets))
+}
+
+// Show gets the data for one Widget. This function is mapped to
+// the path GET /widgets/{widget_id}
+func (v WidgetsResource) Show(c buffalo.Context) error {
+	id, err := uuid.FromString(c.Param("widget_id"))
+	if err != nil {
+		return c.Error(http.StatusNotFound, err)
+	}
+	widget := &models.Widget{ID: id}
+	return c.Render(http.StatusOK, r.Auto(c, widget))
+}
+
+// Create adds a Widget to the DB. This function is mapped to the
+// path POST /widgets
+func (v WidgetsResource) Create(c buffalo.Context) error {
+	widget := &models.Widget{}
+	if err := c.Bind(widget); err != nil {
+		return err
+	}
+	if widget.Title == "" {
+		return c.Render(http.StatusUnprocessableEntity, r.JSON(APIError{Message: "title is missing"}))
+	}
+	return c.Render(http.StatusCreated, r.JSON(widget))
+}
+
+// Update changes a Widget in the DB. This function is mapped to
+// the path PUT /widgets/{widget_id}
+func (v WidgetsResource) Update(c buffalo.Context) error {
+	var widget models.Widget
+	if err := c.Bind(&widget); err != nil {
+		return err
+	}
+	return c.Render(http.StatusOK, r.JSON(widget))
+}
+
+// Destroy deletes a Widget from the DB. This function is mapped
+// to the path DELETE /widgets/{widget_id}
+func (v WidgetsResource) Destroy(c buffalo.Context) error {
+	if c.Param("widget_id") == "" {
+		return c.Error(http.StatusNotFound, fmt.Errorf("widget not found"))
+	}
+	return c.Render(http.StatusNoContent, nil)
+}
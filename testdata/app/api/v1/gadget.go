@@ -0,0 +1,6 @@
+package v1
+
+// Gadget is the gadget of the first API version.
+type Gadget struct {
+	Name string `json:"name"`
+}
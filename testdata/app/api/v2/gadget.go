@@ -0,0 +1,6 @@
+package v2
+
+// Gadget is the gadget of the second API version.
+type Gadget struct {
+	Title string `json:"title"`
+}
@@ -0,0 +1,19 @@
+module coke
+
+go 1.22
+
+require (
+	github.com/gobuffalo/buffalo v0.0.0
+	github.com/gobuffalo/nulls v0.0.0
+	github.com/gobuffalo/pop/v6 v6.0.0
+	github.com/gobuffalo/validate/v3 v3.0.0
+	github.com/gofrs/uuid v0.0.0
+)
+
+replace (
+	github.com/gobuffalo/buffalo => ./stub/buffalo
+	github.com/gobuffalo/nulls => ./stub/nulls
+	github.com/gobuffalo/pop/v6 => ./stub/pop
+	github.com/gobuffalo/validate/v3 => ./stub/validate
+	github.com/gofrs/uuid => ./stub/uuid
+)
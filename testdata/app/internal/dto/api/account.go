@@ -0,0 +1,6 @@
+package api
+
+// Account is the account sent to API clients.
+type Account struct {
+	Login string `json:"login"`
+}
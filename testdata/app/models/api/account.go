@@ -0,0 +1,6 @@
+package api
+
+// Account is the account stored by the models.
+type Account struct {
+	Email string `json:"email"`
+}
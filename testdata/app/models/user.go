@@ -0,0 +1,20 @@
+package models
+
+import (
+	"github.com/gofrs/uuid"
+)
+
+type User struct {
+	ID      uuid.UUID `json:"id" db:"id"`
+	Name    string    `json:"name" db:"name"`
+	Widgets Widgets   `json:"widgets,omitempty" has_many:"widgets" fk_id:"owner_id"`
+}
+
+type Users []User
+
+type Tag struct {
+	ID   int    `json:"id" db:"id"`
+	Name string `json:"name" db:"name"`
+}
+
+type Tags []Tag
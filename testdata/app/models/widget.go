@@ -0,0 +1,77 @@
+package models
+
+import (
+	"time"
+
+	"github.com/gobuffalo/nulls"
+	"github.com/gobuffalo/pop/v6"
+	"github.com/gobuffalo/pop/v6/slices"
+	"github.com/gobuffalo/validate/v3"
+	"github.com/gobuffalo/validate/v3/validators"
+	"github.com/gofrs/uuid"
+)
+
+// Status describes the lifecycle of a Widget.
+type Status string
+
+const (
+	StatusActive   Status = "active"
+	StatusArchived Status = "archived"
+)
+
+// Priority orders the widgets of a listing.
+type Priority int
+
+const (
+	PriorityLow Priority = iota
+	PriorityHigh
+)
+
+func (p Priority) String() string { return [...]string{"low", "high"}[p] }
+
+// Cents is an amount of money.
+type Cents int64
+
+// Widget is a thing we sell.
+type Widget struct {
+	ID uuid.UUID `json:"id" db:"id"`
+	// Title is shown in listings.
+	Title       string        `json:"title" db:"title" form:"widget_title"`
+	Description nulls.String  `json:"description" db:"description"`
+	Price       Cents         `json:"price" db:"price"`
+	Status      Status        `json:"status" db:"status"`
+	Priority    Priority      `json:"priority" db:"priority"`
+	Labels      slices.String `json:"labels" db:"labels"`
+	Count       int           `json:"count" db:"count" swagger:"readOnly"`
+	Secret      string        `json:"-" db:"secret"`
+	Password    string        `json:"-" db:"-" form:"password"`
+	Tags        []Tag         `json:"tags,omitempty" many_to_many:"widget_tags"`
+	OwnerID     uuid.UUID     `json:"owner_id" db:"owner_id"`
+	Owner       *User         `json:"owner,omitempty" belongs_to:"user"`
+	Dimensions  Dimensions    `json:"dimensions" db:"-"`
+	Retired     nulls.Time    `json:"retired" db:"retired"`
+	Timestamps
+}
+
+// Widgets is not required by pop and may be deleted
+type Widgets []Widget
+
+// Dimensions of a widget in millimetres.
+type Dimensions struct {
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+type Timestamps struct {
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Validate gets run every time you call a "pop.Validate*" method.
+func (w *Widget) Validate(tx *pop.Connection) (*validate.Errors, error) {
+	return validate.Validate(
+		&validators.StringIsPresent{Field: w.Title, Name: "Title"},
+		&validators.StringLengthInRange{Field: w.Title, Name: "Title", Min: 3, Max: 64},
+		&validators.IntIsGreaterThan{Field: int(w.Price), Name: "Price", Compared: 0},
+	), nil
+}
@@ -0,0 +1,58 @@
+// Package buffalo stubs the parts of github.com/gobuffalo/buffalo the
+// fixture project uses.
+package buffalo
+
+import (
+	"net/http"
+
+	"github.com/gobuffalo/buffalo/render"
+)
+
+type Handler func(Context) error
+
+type MiddlewareFunc func(Handler) Handler
+
+type ErrorHandler func(int, error, Context) error
+
+type ParamValues interface {
+	Get(string) string
+}
+
+type Context interface {
+	Param(string) string
+	Params() ParamValues
+	Bind(interface{}) error
+	Render(int, render.Renderer) error
+	Error(int, error) error
+	Request() *http.Request
+}
+
+type Resource interface{}
+
+type MiddlewareStack struct{}
+
+func (ms *MiddlewareStack) Use(...MiddlewareFunc)           {}
+func (ms *MiddlewareStack) Skip(MiddlewareFunc, ...Handler) {}
+
+type Options struct {
+	Env         string
+	SessionName string
+}
+
+type App struct {
+	Middleware    *MiddlewareStack
+	ErrorHandlers map[int]ErrorHandler
+}
+
+func New(Options) *App {
+	return &App{Middleware: &MiddlewareStack{}, ErrorHandlers: map[int]ErrorHandler{}}
+}
+
+func (a *App) Use(...MiddlewareFunc)          {}
+func (a *App) GET(string, Handler)            {}
+func (a *App) POST(string, Handler)           {}
+func (a *App) PUT(string, Handler)            {}
+func (a *App) PATCH(string, Handler)          {}
+func (a *App) DELETE(string, Handler)         {}
+func (a *App) Group(string) *App              { return a }
+func (a *App) Resource(string, Resource) *App { return a }
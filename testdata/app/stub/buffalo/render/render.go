@@ -0,0 +1,15 @@
+// Package render stubs github.com/gobuffalo/buffalo/render.
+package render
+
+type Renderer interface{}
+
+type Options struct{}
+
+type Engine struct{}
+
+func New(Options) *Engine { return &Engine{} }
+
+func (e *Engine) JSON(interface{}) Renderer              { return nil }
+func (e *Engine) XML(interface{}) Renderer               { return nil }
+func (e *Engine) Auto(interface{}, interface{}) Renderer { return nil }
+func (e *Engine) String(string, ...interface{}) Renderer { return nil }
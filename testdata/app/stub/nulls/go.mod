@@ -0,0 +1,3 @@
+module github.com/gobuffalo/nulls
+
+go 1.22
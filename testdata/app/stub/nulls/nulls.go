@@ -0,0 +1,14 @@
+// Package nulls stubs github.com/gobuffalo/nulls.
+package nulls
+
+import "time"
+
+type String struct {
+	String string
+	Valid  bool
+}
+
+type Time struct {
+	Time  time.Time
+	Valid bool
+}
@@ -0,0 +1,4 @@
+// Package pop stubs github.com/gobuffalo/pop/v6.
+package pop
+
+type Connection struct{}
@@ -0,0 +1,4 @@
+// Package slices stubs github.com/gobuffalo/pop/v6/slices.
+package slices
+
+type String []string
@@ -0,0 +1,6 @@
+// Package uuid stubs github.com/gofrs/uuid.
+package uuid
+
+type UUID [16]byte
+
+func FromString(string) (UUID, error) { return UUID{}, nil }
@@ -0,0 +1,3 @@
+module github.com/gobuffalo/validate/v3
+
+go 1.22
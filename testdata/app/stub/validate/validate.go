@@ -0,0 +1,10 @@
+// Package validate stubs github.com/gobuffalo/validate/v3.
+package validate
+
+type Errors struct{}
+
+type Validator interface {
+	IsValid(*Errors)
+}
+
+func Validate(...Validator) *Errors { return &Errors{} }
@@ -0,0 +1,28 @@
+// Package validators stubs github.com/gobuffalo/validate/v3/validators.
+package validators
+
+import "github.com/gobuffalo/validate/v3"
+
+type StringIsPresent struct {
+	Name  string
+	Field string
+}
+
+func (v *StringIsPresent) IsValid(*validate.Errors) {}
+
+type StringLengthInRange struct {
+	Name  string
+	Field string
+	Min   int
+	Max   int
+}
+
+func (v *StringLengthInRange) IsValid(*validate.Errors) {}
+
+type IntIsGreaterThan struct {
+	Name     string
+	Field    int
+	Compared int
+}
+
+func (v *IntIsGreaterThan) IsValid(*validate.Errors) {}